@@ -20,12 +20,11 @@ package proton
 import (
 	std_driver "database/sql/driver"
 	"fmt"
-	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
 )
 
 func Named(name string, value interface{}) driver.NamedValue {
@@ -60,13 +59,8 @@ func bind(tz *time.Location, query string, args ...interface{}) (string, error)
 	return bindNumeric(tz, query, args...)
 }
 
-var bindNumericRe = regexp.MustCompile(`\$[0-9]+`)
-
 func bindNumeric(tz *time.Location, query string, args ...interface{}) (_ string, err error) {
-	var (
-		unbind = make(map[string]struct{})
-		params = make(map[string]string)
-	)
+	params := make(map[string]string)
 	for i, v := range args {
 		if fn, ok := v.(std_driver.Valuer); ok {
 			if v, err = fn.Value(); err != nil {
@@ -75,26 +69,15 @@ func bindNumeric(tz *time.Location, query string, args ...interface{}) (_ string
 		}
 		params[fmt.Sprintf("$%d", i+1)] = format(tz, v)
 	}
-	query = bindNumericRe.ReplaceAllStringFunc(query, func(n string) string {
-		if _, found := params[n]; !found {
-			unbind[n] = struct{}{}
-			return ""
-		}
-		return params[n]
-	})
-	for param := range unbind {
+	query, unbound := bindPlaceholders(query, lexer.NumericPlaceholder, params)
+	for _, param := range unbound {
 		return "", fmt.Errorf("have no arg for %s param", param)
 	}
 	return query, nil
 }
 
-var bindNamedRe = regexp.MustCompile(`@[a-zA-Z0-9\_]+`)
-
 func bindNamed(tz *time.Location, query string, args ...interface{}) (_ string, err error) {
-	var (
-		unbind = make(map[string]struct{})
-		params = make(map[string]string)
-	)
+	params := make(map[string]string)
 	for _, v := range args {
 		switch v := v.(type) {
 		case driver.NamedValue:
@@ -107,62 +90,37 @@ func bindNamed(tz *time.Location, query string, args ...interface{}) (_ string,
 			params["@"+v.Name] = format(tz, value)
 		}
 	}
-	query = bindNamedRe.ReplaceAllStringFunc(query, func(n string) string {
-		if _, found := params[n]; !found {
-			unbind[n] = struct{}{}
-			return ""
-		}
-		return params[n]
-	})
-	for param := range unbind {
+	query, unbound := bindPlaceholders(query, lexer.NamedPlaceholder, params)
+	for _, param := range unbound {
 		return "", fmt.Errorf("have no arg for %q param", param)
 	}
 	return query, nil
 }
 
-func format(tz *time.Location, v interface{}) string {
-	quote := func(v string) string {
-		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
-	}
-	switch v := v.(type) {
-	case nil:
-		return "NULL"
-	case string:
-		return quote(v)
-	case time.Time:
-		switch v.Location().String() {
-		case "Local":
-			return fmt.Sprintf("to_datetime(%d)", v.Unix())
-		case tz.String():
-			return v.Format("to_datetime('2006-01-02 15:04:05')")
-		}
-		return v.Format("to_datetime('2006-01-02 15:04:05', '" + v.Location().String() + "')")
-	case []interface{}: // tuple
-		elements := make([]string, 0, len(v))
-		for _, e := range v {
-			elements = append(elements, format(tz, e))
-		}
-		return "(" + strings.Join(elements, ", ") + ")"
-	case [][]interface{}:
-		items := make([]string, 0, len(v))
-		for _, t := range v {
-			items = append(items, format(tz, t))
+// bindPlaceholders replaces the placeholders of kind ($N or @name) that are outside of the literals and
+// the comments of query with their value, and returns the placeholders values has no value for.
+func bindPlaceholders(query string, kind lexer.Kind, values map[string]string) (string, []string) {
+	var (
+		bound   strings.Builder
+		unbound []string
+		last    int
+	)
+	bound.Grow(len(query))
+	for _, t := range lexer.Tokenize(query) {
+		if t.Kind != kind {
+			continue
 		}
-		return strings.Join(items, ", ")
-	case fmt.Stringer:
-		return quote(v.String())
-	}
-	switch v := reflect.ValueOf(v); v.Kind() {
-	case reflect.String:
-		return quote(v.String())
-	case reflect.Slice:
-		values := make([]string, 0, v.Len())
-		for i := 0; i < v.Len(); i++ {
-			values = append(values, format(tz, v.Index(i).Interface()))
+		value, found := values[t.Text]
+		if !found {
+			unbound = append(unbound, t.Text)
+			continue
 		}
-		return strings.Join(values, ", ")
+		bound.WriteString(query[last:t.Pos])
+		bound.WriteString(value)
+		last = t.Pos + len(t.Text)
 	}
-	return fmt.Sprint(v)
+	bound.WriteString(query[last:])
+	return bound.String(), unbound
 }
 
 func rebind(in []std_driver.NamedValue) []interface{} {
@@ -0,0 +1,325 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+)
+
+type (
+	// ArrayValue is rendered as an array literal [...], see Array.
+	ArrayValue struct {
+		Value interface{}
+	}
+	// InValue is rendered as the comma separated list of its elements, see In.
+	InValue struct {
+		Value interface{}
+	}
+	// IdentifierValue is rendered as a quoted identifier, see Identifier.
+	IdentifierValue []string
+	// DateValue is rendered as a date, see Date.
+	DateValue struct {
+		Time time.Time
+	}
+	// DateTime64Value is rendered as a datetime64, see DateTime64.
+	DateTime64Value struct {
+		Time      time.Time
+		Precision int
+	}
+)
+
+// Array binds a slice or an array as an array literal. A slice that is not nested in
+// another value is otherwise bound as a list for IN ($1).
+func Array(v interface{}) ArrayValue {
+	return ArrayValue{Value: v}
+}
+
+// In binds a slice as the list of its elements, for IN ($1). It is always rendered into the
+// query text, even with Options.ServerSideBinding.
+func In(v interface{}) InValue {
+	return InValue{Value: v}
+}
+
+// Identifier binds the name of a database, a stream or a column, Identifier("db", "events")
+// is rendered as `db`.`events`. It is always rendered into the query text.
+func Identifier(parts ...string) IdentifierValue {
+	return IdentifierValue(parts)
+}
+
+// Date binds the date of t, instead of the time.
+func Date(t time.Time) DateValue {
+	return DateValue{Time: t}
+}
+
+// DateTime64 binds t as a datetime64 with precision digits of a second (0 to 9). A time.Time
+// is bound as a datetime, to the second, to compare it with datetime columns.
+func DateTime64(t time.Time, precision int) DateTime64Value {
+	return DateTime64Value{Time: t, Precision: precision}
+}
+
+// precision is Precision within the range of datetime64.
+func (v DateTime64Value) precision() int {
+	switch {
+	case v.Precision < 0:
+		return 0
+	case v.Precision > 9:
+		return 9
+	}
+	return v.Precision
+}
+
+func (id IdentifierValue) String() string {
+	parts := make([]string, 0, len(id))
+	for _, part := range id {
+		parts = append(parts, lexer.QuoteIdentifier(part))
+	}
+	return strings.Join(parts, ".")
+}
+
+// format renders an arg into the text of a query. Slices that are not nested in another value
+// are rendered as lists, for IN ($1), as well as [][]interface{}, a list of tuples.
+func format(tz *time.Location, v interface{}) string {
+	switch v := v.(type) {
+	case InValue:
+		return formatList(tz, reflect.ValueOf(v.Value))
+	case IdentifierValue:
+		return v.String()
+	case [][]interface{}:
+		items := make([]string, 0, len(v))
+		for _, t := range v {
+			items = append(items, literal(tz, t))
+		}
+		return strings.Join(items, ", ")
+	}
+	if v := reflect.ValueOf(v); v.Kind() == reflect.Slice && !isLiteralSlice(v.Type()) {
+		return formatList(tz, v)
+	}
+	return literal(tz, v)
+}
+
+func formatList(tz *time.Location, v reflect.Value) string {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return literal(tz, v.Interface())
+	}
+	values := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		values = append(values, literal(tz, v.Index(i).Interface()))
+	}
+	return strings.Join(values, ", ")
+}
+
+// isLiteralSlice reports whether a slice is rendered as a single literal: []byte is
+// a string, []interface{} a tuple and uuid.UUID and net.IP are not lists either.
+func isLiteralSlice(t reflect.Type) bool {
+	switch {
+	case t == ipType, t.Elem().Kind() == reflect.Uint8, t.Elem().Kind() == reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// quoteBytes renders binary data as a string literal, the bytes that are not printable are escaped.
+func quoteBytes(v []byte) string {
+	var s strings.Builder
+	s.Grow(len(v) + 2)
+	s.WriteByte('\'')
+	for _, b := range v {
+		switch {
+		case b == '\\' || b == '\'':
+			s.WriteByte('\\')
+			s.WriteByte(b)
+		case b < 0x20 || b >= 0x7f:
+			fmt.Fprintf(&s, `\x%02X`, b)
+		default:
+			s.WriteByte(b)
+		}
+	}
+	s.WriteByte('\'')
+	return s.String()
+}
+
+// literal renders a value as a literal of its type, slices and arrays as arrays.
+func literal(tz *time.Location, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return lexer.QuoteString(v)
+	case []byte:
+		return quoteBytes(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case time.Time:
+		return formatTime(tz, v)
+	case DateValue:
+		return "to_date('" + v.Time.Format("2006-01-02") + "')"
+	case DateTime64Value:
+		return formatTime64(tz, v.Time, v.precision())
+	case uuid.UUID:
+		return "to_uuid('" + v.String() + "')"
+	case net.IP:
+		if v.To4() == nil && len(v) == net.IPv6len {
+			return "to_ipv6('" + v.String() + "')"
+		}
+		return "to_ipv4('" + v.String() + "')"
+	case decimal.Decimal:
+		var scale int32
+		if exp := v.Exponent(); exp < 0 {
+			scale = -exp
+		}
+		fn := "to_decimal128"
+		if decimalPrecision(v) > 38 {
+			fn = "to_decimal256"
+		}
+		return fmt.Sprintf("%s('%s', %d)", fn, v.String(), scale)
+	case ArrayValue:
+		return formatArray(tz, reflect.ValueOf(v.Value))
+	case InValue:
+		return formatList(tz, reflect.ValueOf(v.Value))
+	case IdentifierValue:
+		return v.String()
+	case []interface{}: // tuple
+		elements := make([]string, 0, len(v))
+		for _, e := range v {
+			elements = append(elements, literal(tz, e))
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	case fmt.Stringer:
+		if rv := reflect.ValueOf(v); rv.Kind() != reflect.Ptr || !rv.IsNil() {
+			return lexer.QuoteString(v.String())
+		}
+		return "NULL"
+	}
+	switch v := reflect.ValueOf(v); v.Kind() {
+	case reflect.String:
+		return lexer.QuoteString(v.String())
+	case reflect.Float32:
+		return formatFloat(v.Float(), 32)
+	case reflect.Float64:
+		return formatFloat(v.Float(), 64)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "NULL"
+		}
+		return literal(tz, v.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			return quoteBytes(data)
+		}
+		return formatArray(tz, v)
+	case reflect.Map:
+		if v.IsNil() || v.Len() == 0 {
+			return "map()"
+		}
+		type entry struct{ key, value string }
+		entries := make([]entry, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			entries = append(entries, entry{
+				key:   literal(tz, iter.Key().Interface()),
+				value: literal(tz, iter.Value().Interface()),
+			})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		elements := make([]string, 0, 2*len(entries))
+		for _, e := range entries {
+			elements = append(elements, e.key, e.value)
+		}
+		return "map(" + strings.Join(elements, ", ") + ")"
+	}
+	return fmt.Sprint(v)
+}
+
+func formatArray(tz *time.Location, v reflect.Value) string {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return literal(tz, v.Interface())
+	}
+	elements := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elements = append(elements, literal(tz, v.Index(i).Interface()))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func formatFloat(v float64, bits int) string {
+	switch {
+	case math.IsNaN(v):
+		return "nan"
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, bits)
+}
+
+// formatTime renders a time as a datetime, to the second. A time in the timezone of the server
+// is sent without its timezone.
+func formatTime(tz *time.Location, v time.Time) string {
+	switch v.Location().String() {
+	case "Local":
+		return fmt.Sprintf("to_datetime(%d)", v.Unix())
+	case tz.String():
+		return v.Format("to_datetime('2006-01-02 15:04:05')")
+	}
+	return v.Format("to_datetime('2006-01-02 15:04:05', '" + v.Location().String() + "')")
+}
+
+// formatTime64 renders a time as a datetime64 with precision digits of a second.
+func formatTime64(tz *time.Location, v time.Time, precision int) string {
+	if v.Location().String() == "Local" {
+		v = v.UTC()
+	}
+	layout := "2006-01-02 15:04:05"
+	if precision > 0 {
+		layout += "." + strings.Repeat("0", precision)
+	}
+	if v.Location().String() == tz.String() {
+		return fmt.Sprintf("to_datetime64('%s', %d)", v.Format(layout), precision)
+	}
+	return fmt.Sprintf("to_datetime64('%s', %d, '%s')", v.Format(layout), precision, v.Location())
+}
+
+// decimalPrecision is the number of digits of v: the digits of its coefficient, without
+// the sign, and the zeros of a positive exponent.
+func decimalPrecision(v decimal.Decimal) int {
+	precision := len(new(big.Int).Abs(v.Coefficient()).String())
+	if exp := v.Exponent(); exp > 0 {
+		precision += int(exp)
+	}
+	return precision
+}
@@ -0,0 +1,120 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatLiterals(t *testing.T) {
+	var (
+		str    = "it's"
+		nilPtr *int
+		id     = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	)
+	for _, c := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{&str, `'it\'s'`},
+		{nilPtr, "NULL"},
+		{[]byte("a'\x00\xff"), `'a\'\x00\xFF'`},
+		{true, "true"},
+		{math.NaN(), "nan"},
+		{math.Inf(1), "inf"},
+		{float32(math.Inf(-1)), "-inf"},
+		{1.5, "1.5"},
+		{id, "to_uuid('f47ac10b-58cc-4372-a567-0e02b2c3d479')"},
+		{net.ParseIP("192.168.0.1"), "to_ipv4('192.168.0.1')"},
+		{net.ParseIP("2001:db8::1"), "to_ipv6('2001:db8::1')"},
+		{decimal.RequireFromString("12.340"), "to_decimal128('12.34', 3)"},
+		{decimal.RequireFromString("1"), "to_decimal128('1', 0)"},
+		{decimal.New(1, 40), "to_decimal256('10000000000000000000000000000000000000000', 0)"},
+		{decimal.RequireFromString("-" + strings.Repeat("9", 38)), "to_decimal128('-" + strings.Repeat("9", 38) + "', 0)"},
+		{decimal.RequireFromString(strings.Repeat("9", 39)), "to_decimal256('" + strings.Repeat("9", 39) + "', 0)"},
+		{[]int{1, 2}, "1, 2"},
+		{[]string{"a", "b"}, "'a', 'b'"},
+		{In([]int{1, 2}), "1, 2"},
+		{Array([]int{1, 2}), "[1, 2]"},
+		{Array([][]string{{"a"}, {}}), "[['a'], []]"},
+		{[]interface{}{"a", 1, []int{1}}, "('a', 1, [1])"},
+		{[][]interface{}{{"a", 1}, {"b", 2}}, "('a', 1), ('b', 2)"},
+		{map[string][]int{"b": {2}, "a": {1}}, "map('a', [1], 'b', [2])"},
+		{map[string]int{}, "map()"},
+		{Array([]map[string]interface{}{{"k": []interface{}{1, "x"}}}), "[map('k', (1, 'x'))]"},
+		{Identifier("events"), "`events`"},
+		{Identifier("db", "we`ird"), "`db`.`we\\`ird`"},
+		{Date(time.Date(2022, 1, 12, 23, 0, 0, 0, time.UTC)), "to_date('2022-01-12')"},
+		{time.Second, "'1s'"},
+	} {
+		assert.Equal(t, c.expected, format(time.UTC, c.value), "%#v", c.value)
+	}
+}
+
+func TestFormatTimePrecision(t *testing.T) {
+	tz, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+	for _, c := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{time.Date(2022, 1, 12, 15, 0, 0, 0, time.UTC), "to_datetime('2022-01-12 15:00:00')"},
+		// a time.Time is a datetime, compared to the second with datetime columns
+		{time.Date(2022, 1, 12, 15, 0, 0, int(123*time.Millisecond), time.UTC), "to_datetime('2022-01-12 15:00:00')"},
+		{DateTime64(time.Date(2022, 1, 12, 15, 0, 0, int(123*time.Millisecond), time.UTC), 3), "to_datetime64('2022-01-12 15:00:00.123', 3)"},
+		{DateTime64(time.Date(2022, 1, 12, 15, 0, 0, int(123456*time.Microsecond), time.UTC), 6), "to_datetime64('2022-01-12 15:00:00.123456', 6)"},
+		{DateTime64(time.Date(2022, 1, 12, 15, 0, 0, 1, tz), 9), "to_datetime64('2022-01-12 15:00:00.000000001', 9, 'Europe/London')"},
+		{DateTime64(time.Date(2022, 1, 12, 15, 0, 0, 1, time.UTC), 0), "to_datetime64('2022-01-12 15:00:00', 0)"},
+		{DateTime64(time.Date(2022, 1, 12, 15, 0, 0, 1, time.UTC), 12), "to_datetime64('2022-01-12 15:00:00.000000001', 9)"},
+	} {
+		assert.Equal(t, c.expected, format(time.UTC, c.value))
+	}
+}
+
+func TestBindWrappers(t *testing.T) {
+	query, err := bind(time.UTC, "SELECT * FROM $1 WHERE id IN ($2) AND has($3, tag)", Identifier("db", "events"), In([]int{1, 2}), Array([]string{"a"}))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `db`.`events` WHERE id IN (1, 2) AND has(['a'], tag)", query)
+
+	query, params, err := bindParameters(time.UTC, "SELECT * FROM @stream WHERE id IN (@ids) AND has(@tags, tag) AND d = @day",
+		Named("stream", Identifier("events")),
+		Named("ids", In([]int{1, 2})),
+		Named("tags", Array([]string{"a"})),
+		Named("day", Date(time.Date(2022, 1, 12, 0, 0, 0, 0, time.UTC))),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `events` WHERE id IN (1, 2) AND has({tags:array(string)}, tag) AND d = {day:date}", query)
+	assert.Equal(t, map[string]string{"tags": "['a']", "day": "2022-01-12"}, params)
+
+	query, params, err = bindParameters(time.UTC, "SELECT * FROM events WHERE t = @t",
+		Named("t", DateTime64(time.Date(2022, 1, 12, 15, 0, 0, int(123*time.Millisecond), time.UTC), 3)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM events WHERE t = {t:datetime64(3, 'UTC')}", query)
+	assert.Equal(t, map[string]string{"t": "2022-01-12 15:00:00.123"}, params)
+}
@@ -0,0 +1,303 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	std_driver "database/sql/driver"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+)
+
+// bind renders args into the query, or with Options.ServerSideBinding
+// turns them into query parameters of options.
+func (c *connect) bind(options *QueryOptions, query string, args ...interface{}) (string, error) {
+	if !c.opt.ServerSideBinding {
+		return bind(c.server.Timezone, query, args...)
+	}
+	query, params, err := bindParameters(c.server.Timezone, query, args...)
+	if err != nil {
+		return "", err
+	}
+	if len(params) != 0 {
+		options.parameters = params
+	}
+	return query, nil
+}
+
+// bindParameters replaces the $N and @name placeholders with {pN:Type} and {name:Type},
+// where Type is inferred from the Go value, and returns the values in their text format.
+// Named args are sent even if the query does not use @name, so a query can declare
+// {name:Type} itself when the inferred type is not the right one. In and Identifier
+// values are rendered into the query like bind does.
+func bindParameters(tz *time.Location, query string, args ...interface{}) (_ string, _ map[string]string, err error) {
+	if len(args) == 0 {
+		return query, nil, nil
+	}
+	var (
+		kind    = lexer.NumericPlaceholder
+		prefix  = "$"
+		params  = make(map[string]string, len(args))
+		typed   = make(map[string]string, len(args))
+		numeric int
+	)
+	for _, v := range args {
+		if _, ok := v.(driver.NamedValue); !ok {
+			numeric++
+		}
+	}
+	haveName := numeric == 0
+	if !haveName && numeric != len(args) {
+		return "", nil, ErrBindMixedNamedAndNumericParams
+	}
+	for i, v := range args {
+		name := "p" + strconv.Itoa(i+1)
+		if named, ok := v.(driver.NamedValue); ok {
+			name, v = named.Name, named.Value
+		}
+		if fn, ok := v.(std_driver.Valuer); ok {
+			if v, err = fn.Value(); err != nil {
+				return "", nil, err
+			}
+		}
+		placeholder := "$" + strconv.Itoa(i+1)
+		if haveName {
+			placeholder = "@" + name
+		}
+		switch a := v.(type) {
+		case InValue, IdentifierValue:
+			typed[placeholder] = format(tz, v)
+			continue
+		case ArrayValue:
+			v = a.Value
+		}
+		value := reflect.ValueOf(v)
+		t, err := parameterType(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("proton [bind]: %s: %w", name, err)
+		}
+		if params[name], err = parameterValue(value, false); err != nil {
+			return "", nil, fmt.Errorf("proton [bind]: %s: %w", name, err)
+		}
+		typed[placeholder] = "{" + name + ":" + t + "}"
+	}
+	if haveName {
+		kind, prefix = lexer.NamedPlaceholder, "@"
+	}
+	query, unbound := bindPlaceholders(query, kind, typed)
+	for _, param := range unbound {
+		return "", nil, fmt.Errorf("have no arg for %s param", strings.TrimPrefix(param, prefix))
+	}
+	return query, params, nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	ipType      = reflect.TypeOf(net.IP{})
+	dateType    = reflect.TypeOf(DateValue{})
+	dateTime64  = reflect.TypeOf(DateTime64Value{})
+)
+
+// parameterType infers the Proton type of a value. Times are sent as datetime64(9, 'UTC')
+// and decimals with the scale of the value (of the first element for arrays and maps).
+func parameterType(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "nullable(nothing)", nil
+	}
+	switch v.Type() {
+	case timeType:
+		return "datetime64(9, 'UTC')", nil
+	case dateType:
+		return "date", nil
+	case dateTime64:
+		return fmt.Sprintf("datetime64(%d, 'UTC')", v.Interface().(DateTime64Value).precision()), nil
+	case uuidType:
+		return "uuid", nil
+	case decimalType:
+		scale := int32(0)
+		if exp := v.Interface().(decimal.Decimal).Exponent(); exp < 0 {
+			scale = -exp
+		}
+		return fmt.Sprintf("decimal(76, %d)", scale), nil
+	case ipType:
+		if ip := v.Interface().(net.IP); len(ip) != 0 && ip.To4() == nil {
+			return "ipv6", nil
+		}
+		return "ipv4", nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return "bool", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.Kind().String(), nil
+	case reflect.Int:
+		return "int64", nil
+	case reflect.Uint:
+		return "uint64", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Interface:
+		return parameterType(v.Elem())
+	case reflect.Ptr:
+		elem := reflect.Zero(v.Type().Elem())
+		if !v.IsNil() {
+			elem = v.Elem()
+		}
+		t, err := parameterType(elem)
+		if err != nil {
+			return "", err
+		}
+		return "nullable(" + t + ")", nil
+	case reflect.Slice, reflect.Array:
+		switch {
+		case v.Type().Elem().Kind() == reflect.Uint8:
+			return "string", nil
+		case v.Type().Elem().Kind() == reflect.Interface: // tuple
+			elements := make([]string, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				t, err := parameterType(v.Index(i))
+				if err != nil {
+					return "", err
+				}
+				elements = append(elements, t)
+			}
+			return "tuple(" + strings.Join(elements, ", ") + ")", nil
+		}
+		elem := reflect.Zero(v.Type().Elem())
+		if v.Len() != 0 {
+			elem = v.Index(0)
+		}
+		t, err := parameterType(elem)
+		if err != nil {
+			return "", err
+		}
+		return "array(" + t + ")", nil
+	case reflect.Map:
+		key, value := reflect.Zero(v.Type().Key()), reflect.Zero(v.Type().Elem())
+		if iter := v.MapRange(); iter.Next() {
+			key, value = iter.Key(), iter.Value()
+		}
+		k, err := parameterType(key)
+		if err != nil {
+			return "", err
+		}
+		e, err := parameterType(value)
+		if err != nil {
+			return "", err
+		}
+		return "map(" + k + ", " + e + ")", nil
+	}
+	return "", fmt.Errorf("cannot infer the type of %s", v.Type())
+}
+
+// textReplacer escapes the values of the text format that are not quoted.
+var textReplacer = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`)
+
+// parameterValue renders a value in the text format of its type. Nested values
+// (elements of arrays, maps and tuples) are rendered quoted.
+func parameterValue(v reflect.Value, quoted bool) (string, error) {
+	quote := func(s string) string {
+		if quoted {
+			return lexer.QuoteString(s)
+		}
+		return textReplacer.Replace(s)
+	}
+	if !v.IsValid() {
+		if quoted {
+			return "NULL", nil
+		}
+		return `\N`, nil
+	}
+	switch v.Type() {
+	case timeType:
+		return quote(v.Interface().(time.Time).UTC().Format("2006-01-02 15:04:05.999999999")), nil
+	case dateType:
+		return quote(v.Interface().(DateValue).Time.Format("2006-01-02")), nil
+	case dateTime64:
+		return quote(v.Interface().(DateTime64Value).Time.UTC().Format("2006-01-02 15:04:05.999999999")), nil
+	case uuidType, decimalType:
+		return quote(v.Interface().(fmt.Stringer).String()), nil
+	case ipType:
+		return quote(v.Interface().(net.IP).String()), nil
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(v.Interface()), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.String:
+		return quote(v.String()), nil
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return parameterValue(reflect.Value{}, quoted)
+		}
+		return parameterValue(v.Elem(), quoted)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(data), v)
+			return quote(string(data)), nil
+		}
+		open, close := "[", "]"
+		if v.Type().Elem().Kind() == reflect.Interface {
+			open, close = "(", ")"
+		}
+		elements := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := parameterValue(v.Index(i), true)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, e)
+		}
+		return open + strings.Join(elements, ",") + close, nil
+	case reflect.Map:
+		elements := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k, err := parameterValue(iter.Key(), true)
+			if err != nil {
+				return "", err
+			}
+			e, err := parameterValue(iter.Value(), true)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, k+":"+e)
+		}
+		sort.Strings(elements)
+		return "{" + strings.Join(elements, ",") + "}", nil
+	}
+	return "", fmt.Errorf("unsupported value of type %s", v.Type())
+}
@@ -0,0 +1,104 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindParametersNumeric(t *testing.T) {
+	query, params, err := bindParameters(time.UTC, "SELECT $2, $1, $2 FROM t WHERE s = $3", int32(1), uint64(2), "str")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT {p2:uint64}, {p1:int32}, {p2:uint64} FROM t WHERE s = {p3:string}", query)
+	assert.Equal(t, map[string]string{"p1": "1", "p2": "2", "p3": "str"}, params)
+
+	_, _, err = bindParameters(time.UTC, "SELECT $1, $2", 1)
+	assert.Error(t, err)
+	_, _, err = bindParameters(time.UTC, "SELECT $1, @name", 1, Named("name", 2))
+	assert.Equal(t, ErrBindMixedNamedAndNumericParams, err)
+	_, _, err = bindParameters(time.UTC, "SELECT $1, @name", Named("name", 2), 1)
+	assert.Equal(t, ErrBindMixedNamedAndNumericParams, err)
+}
+
+func TestBindParametersNamed(t *testing.T) {
+	query, params, err := bindParameters(time.UTC, "SELECT @a, {b:int8}", Named("a", []string{"x"}), Named("b", 42))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT {a:array(string)}, {b:int8}", query)
+	assert.Equal(t, map[string]string{"a": "['x']", "b": "42"}, params)
+
+	query, params, err = bindParameters(time.UTC, "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT 1", query)
+	assert.Nil(t, params)
+}
+
+func TestParameterTypeAndValue(t *testing.T) {
+	var (
+		str  = "it's"
+		null *string
+		ts   = time.Date(2022, 1, 12, 15, 0, 0, 123000000, time.FixedZone("", 3600))
+		id   = uuid.MustParse("a7b2a1c4-5d4d-4ab5-94b6-8c0a3e4c1f11")
+	)
+	for _, c := range []struct {
+		value interface{}
+		t     string
+		text  string
+	}{
+		{nil, "nullable(nothing)", `\N`},
+		{true, "bool", "true"},
+		{int(-1), "int64", "-1"},
+		{uint16(1), "uint16", "1"},
+		{float32(1.5), "float32", "1.5"},
+		{"a\tb\\c'd", "string", `a\tb\\c'd`},
+		{[]byte("bytes"), "string", "bytes"},
+		{&str, "nullable(string)", "it's"},
+		{null, "nullable(string)", `\N`},
+		{ts, "datetime64(9, 'UTC')", "2022-01-12 14:00:00.123"},
+		{id, "uuid", id.String()},
+		{decimal.RequireFromString("12.345"), "decimal(76, 3)", "12.345"},
+		{net.ParseIP("127.0.0.1"), "ipv4", "127.0.0.1"},
+		{net.ParseIP("::1"), "ipv6", "::1"},
+		{[]string{"a", "it's"}, "array(string)", `['a','it\'s']`},
+		{[][]int64{{1}, {2, 3}}, "array(array(int64))", "[[1],[2,3]]"},
+		{[]*string{&str, nil}, "array(nullable(string))", `['it\'s',NULL]`},
+		{[]int8{}, "array(int8)", "[]"},
+		{map[string]uint8{"b": 2, "a": 1}, "map(string, uint8)", "{'a':1,'b':2}"},
+		{[]interface{}{"a", int16(1), nil}, "tuple(string, int16, nullable(nothing))", "('a',1,NULL)"},
+		{[]time.Time{ts}, "array(datetime64(9, 'UTC'))", "['2022-01-12 14:00:00.123']"},
+	} {
+		v := reflect.ValueOf(c.value)
+		typ, err := parameterType(v)
+		if assert.NoError(t, err, "%T", c.value) {
+			assert.Equal(t, c.t, typ, "%T", c.value)
+		}
+		text, err := parameterValue(v, false)
+		if assert.NoError(t, err, "%T", c.value) {
+			assert.Equal(t, c.text, text, "%T", c.value)
+		}
+	}
+	_, err := parameterType(reflect.ValueOf(struct{}{}))
+	assert.Error(t, err)
+}
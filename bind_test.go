@@ -191,3 +191,21 @@ func BenchmarkBindNamed(b *testing.B) {
 		}
 	}
 }
+
+func TestBindIgnoresLiteralsAndComments(t *testing.T) {
+	actual, err := bind(time.Local, "SELECT $1, '$2', `$3` -- $4\n/* $5 */ FROM t", "a")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "SELECT 'a', '$2', `$3` -- $4\n/* $5 */ FROM t", actual)
+	}
+	actual, err = bind(time.Local, "SELECT @name, 'user@example.com', \"@col\" -- @other", Named("name", 1))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "SELECT 1, 'user@example.com', \"@col\" -- @other", actual)
+	}
+	query, params, err := bindParameters(time.UTC, "SELECT $1, '$2' /* $3 */", 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "SELECT {p1:int64}, '$2' /* $3 */", query)
+		assert.Equal(t, map[string]string{"p1": "1"}, params)
+	}
+	_, err = bind(time.Local, "SELECT $1, $2", 1)
+	assert.Error(t, err)
+}
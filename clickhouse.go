@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -44,6 +45,7 @@ var (
 	ErrAcquireConnTimeout             = errors.New("proton: acquire conn timeout. you can increase the number of max open conn or the dial timeout")
 	ErrUnsupportedServerRevision      = errors.New("proton: unsupported server revision")
 	ErrBindMixedNamedAndNumericParams = errors.New("proton [bind]: mixed named and numeric parameters")
+	ErrBatchWriterClosed              = errors.New("proton: batch writer is closed")
 )
 
 type OpError struct {
@@ -70,23 +72,55 @@ func (e *OpError) Error() string {
 	return fmt.Sprintf("proton [%s]: %s", e.Op, e.Err)
 }
 
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
 func Open(opt *Options) (driver.Conn, error) {
 	opt.setDefaults()
-	return &proton{
-		opt:  opt,
-		idle: make(chan *connect, opt.MaxIdleConns),
-		open: make(chan struct{}, opt.MaxOpenConns),
-	}, nil
+	ch := &proton{
+		opt:   opt,
+		idle:  make(chan *connect, opt.MaxIdleConns),
+		open:  make(chan struct{}, opt.MaxOpenConns),
+		hosts: newHosts(opt),
+		done:  make(chan struct{}),
+	}
+	if opt.ConnMaxIdleTime > 0 || opt.MinIdleConns > 0 {
+		ch.wg.Add(1)
+		go ch.reaper()
+	}
+	if observer, ok := opt.Instrumentation.(PoolObserver); ok {
+		ch.stopObserver = observer.ObservePool(ch.Stats)
+	}
+	return ch, nil
 }
 
 type proton struct {
-	opt    *Options
-	idle   chan *connect
-	open   chan struct{}
-	connID int64
+	stats        poolStats
+	opt          *Options
+	idle         chan *connect
+	open         chan struct{}
+	hosts        *hosts
+	stopObserver func()
+	connID       int64
+	done         chan struct{}
+	closed       sync.Once
+	wg           sync.WaitGroup
+}
+
+// poolStats are the counters of driver.Stats, updated atomically.
+type poolStats struct {
+	waitCount         int64
+	waitDuration      int64
+	maxIdleClosed     int64
+	maxIdleTimeClosed int64
+	maxLifetimeClosed int64
+	dials             int64
+	dialErrors        int64
+	acquireTimeouts   int64
 }
 
-func (proton) Contributors() []string {
+func (*proton) Contributors() []string {
 	list := contributors.List
 	if len(list[len(list)-1]) == 0 {
 		return list[:len(list)-1]
@@ -108,90 +142,213 @@ func (ch *proton) ServerVersion() (*driver.ServerVersion, error) {
 }
 
 func (ch *proton) Query(ctx context.Context, query string, args ...interface{}) (rows driver.Rows, err error) {
-	conn, err := ch.acquire(ctx)
+	result, err := ch.opt.intercept(ctx, &Invocation{Method: MethodQuery, Query: query, Args: args}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := conn.query(ctx, ch.release, inv.Query, inv.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return rows, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return conn.query(ctx, ch.release, query, args...)
+	if rows, ok := result.(driver.Rows); ok && rows != nil {
+		return rows, nil
+	}
+	return nil, interceptResult(MethodQuery, result)
 }
 
 func (ch *proton) QueryRow(ctx context.Context, query string, args ...interface{}) (rows driver.Row) {
-	conn, err := ch.acquire(ctx)
+	result, err := ch.opt.intercept(ctx, &Invocation{Method: MethodQueryRow, Query: query, Args: args}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return conn.queryRow(ctx, ch.release, inv.Query, inv.Args...), nil
+	})
 	if err != nil {
 		return &row{
 			err: err,
 		}
 	}
-	return conn.queryRow(ctx, ch.release, query, args...)
+	if row, ok := result.(driver.Row); ok && row != nil {
+		return row
+	}
+	return &row{
+		err: interceptResult(MethodQueryRow, result),
+	}
 }
 
-func (ch *proton) Exec(ctx context.Context, query string, args ...interface{}) error {
-	conn, err := ch.acquire(ctx)
+func (ch *proton) Stream(ctx context.Context, query string, args ...interface{}) (driver.Stream, error) {
+	result, err := ch.opt.intercept(ctx, &Invocation{Method: MethodStream, Query: query, Args: args}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		if options := queryOptions(ctx); options.resume != nil {
+			return ch.resumableStream(ctx, *options.resume, inv.Query, inv.Args...)
+		}
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stream, err := conn.subscribe(ctx, ch.release, inv.Query, inv.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return stream, nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := conn.exec(ctx, query, args...); err != nil {
-		ch.release(conn, err)
-		return err
+	if stream, ok := result.(driver.Stream); ok && stream != nil {
+		return stream, nil
 	}
-	ch.release(conn, nil)
-	return nil
+	return nil, interceptResult(MethodStream, result)
 }
 
-func (ch *proton) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
-	conn, err := ch.acquire(ctx)
+func (ch *proton) Exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := ch.ExecWithResult(ctx, query, args...)
+	return err
+}
+
+func (ch *proton) ExecWithResult(ctx context.Context, query string, args ...interface{}) (*ExecResult, error) {
+	result, err := ch.opt.intercept(ctx, &Invocation{Method: MethodExec, Query: query, Args: args}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result, err := conn.execWithResult(ctx, inv.Query, inv.Args...)
+		if err != nil {
+			ch.release(conn, err)
+			return nil, err
+		}
+		ch.release(conn, nil)
+		return result, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return conn.prepareBatch(ctx, query, ch.release)
+	switch result := result.(type) {
+	case *ExecResult:
+		if result != nil {
+			return result, nil
+		}
+	case nil:
+	default:
+		return nil, interceptResult(MethodExec, result)
+	}
+	// an interceptor answered without running the query
+	return &ExecResult{}, nil
 }
 
-func (ch *proton) AsyncInsert(ctx context.Context, query string, wait bool) error {
-	conn, err := ch.acquire(ctx)
+func (ch *proton) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	result, err := ch.opt.intercept(ctx, &Invocation{Method: MethodPrepareBatch, Query: query}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		if options := queryOptions(ctx); options.retry != nil {
+			return ch.prepareRetryableBatch(ctx, *options.retry, inv.Query)
+		}
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		batch, err := conn.prepareBatch(ctx, inv.Query, ch.release)
+		if err != nil {
+			return nil, err
+		}
+		return batch, nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := conn.asyncInsert(ctx, query, wait); err != nil {
-		ch.release(conn, err)
-		return err
+	if batch, ok := result.(driver.Batch); ok && batch != nil {
+		return batch, nil
 	}
-	ch.release(conn, nil)
-	return nil
+	return nil, interceptResult(MethodPrepareBatch, result)
+}
+
+func (ch *proton) AsyncInsert(ctx context.Context, query string, wait bool) error {
+	_, err := ch.opt.intercept(ctx, &Invocation{Method: MethodAsyncInsert, Query: query}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.asyncInsert(ctx, inv.Query, wait); err != nil {
+			ch.release(conn, err)
+			return nil, err
+		}
+		ch.release(conn, nil)
+		return nil, nil
+	})
+	return err
 }
 
 func (ch *proton) Ping(ctx context.Context) (err error) {
-	conn, err := ch.acquire(ctx)
-	if err != nil {
-		return err
-	}
-	if err := conn.ping(ctx); err != nil {
-		ch.release(conn, err)
-		return err
-	}
-	ch.release(conn, nil)
-	return nil
+	_, err = ch.opt.intercept(ctx, &Invocation{Method: MethodPing}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		conn, err := ch.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := conn.ping(ctx); err != nil {
+			ch.release(conn, err)
+			return nil, err
+		}
+		ch.release(conn, nil)
+		return nil, nil
+	})
+	return err
 }
 
 func (ch *proton) Stats() driver.Stats {
 	return driver.Stats{
-		Open:         len(ch.open),
-		Idle:         len(ch.idle),
-		MaxOpenConns: cap(ch.open),
-		MaxIdleConns: cap(ch.idle),
+		Open:              len(ch.open),
+		Idle:              len(ch.idle),
+		MaxOpenConns:      cap(ch.open),
+		MaxIdleConns:      cap(ch.idle),
+		WaitCount:         atomic.LoadInt64(&ch.stats.waitCount),
+		WaitDuration:      time.Duration(atomic.LoadInt64(&ch.stats.waitDuration)),
+		MaxIdleClosed:     atomic.LoadInt64(&ch.stats.maxIdleClosed),
+		MaxIdleTimeClosed: atomic.LoadInt64(&ch.stats.maxIdleTimeClosed),
+		MaxLifetimeClosed: atomic.LoadInt64(&ch.stats.maxLifetimeClosed),
+		Dials:             atomic.LoadInt64(&ch.stats.dials),
+		DialErrors:        atomic.LoadInt64(&ch.stats.dialErrors),
+		AcquireTimeouts:   atomic.LoadInt64(&ch.stats.acquireTimeouts),
+		Hosts:             ch.hosts.stats(),
 	}
 }
 
 func (ch *proton) dial(ctx context.Context) (conn *connect, err error) {
-	connID := int(atomic.AddInt64(&ch.connID, 1))
-	for num := range ch.opt.Addr {
-		if ch.opt.ConnOpenStrategy == ConnOpenRoundRobin {
-			num = int(connID) % len(ch.opt.Addr)
-		}
-		if conn, err = dial(ctx, ch.opt.Addr[num], connID, ch.opt); err == nil {
-			return conn, nil
-		}
+	atomic.AddInt64(&ch.stats.dials, 1)
+	if conn, err = ch.hosts.dial(ctx, int(atomic.AddInt64(&ch.connID, 1))); err != nil {
+		atomic.AddInt64(&ch.stats.dialErrors, 1)
+		return nil, err
 	}
-	return nil, err
+	conn.lastUsedIn = conn.connectedAt
+	conn.kill = ch.killQuery
+	return conn, nil
+}
+
+// killQuery kills a query that ignored its cancel, see connect.cancel.
+func (ch *proton) killQuery(ctx context.Context, queryID string) error {
+	conn, err := ch.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	err = conn.killQuery(ctx, queryID)
+	ch.release(conn, err)
+	return err
+}
+
+// expired reports whether conn outlived ConnMaxLifetime or ConnMaxIdleTime, and counts why.
+func (ch *proton) expired(conn *connect, now time.Time) bool {
+	switch {
+	case now.Sub(conn.connectedAt) >= ch.opt.ConnMaxLifetime:
+		atomic.AddInt64(&ch.stats.maxLifetimeClosed, 1)
+		return true
+	case ch.opt.ConnMaxIdleTime > 0 && now.Sub(conn.lastUsedIn) >= ch.opt.ConnMaxIdleTime:
+		atomic.AddInt64(&ch.stats.maxIdleTimeClosed, 1)
+		return true
+	}
+	return false
 }
 
 func (ch *proton) acquire(ctx context.Context) (conn *connect, err error) {
@@ -203,15 +360,25 @@ func (ch *proton) acquire(ctx context.Context) (conn *connect, err error) {
 	default:
 	}
 	select {
-	case <-timer.C:
-		return nil, ErrAcquireConnTimeout
 	case ch.open <- struct{}{}:
+	default:
+		start := time.Now()
+		atomic.AddInt64(&ch.stats.waitCount, 1)
+		select {
+		case <-timer.C:
+			atomic.AddInt64(&ch.stats.waitDuration, int64(time.Since(start)))
+			atomic.AddInt64(&ch.stats.acquireTimeouts, 1)
+			return nil, ErrAcquireConnTimeout
+		case ch.open <- struct{}{}:
+			atomic.AddInt64(&ch.stats.waitDuration, int64(time.Since(start)))
+		}
 	}
 	select {
 	case <-timer.C:
+		atomic.AddInt64(&ch.stats.acquireTimeouts, 1)
 		return nil, ErrAcquireConnTimeout
 	case conn := <-ch.idle:
-		if conn.isBad() {
+		if conn.isBad() || ch.expired(conn, time.Now()) {
 			conn.close()
 			if conn, err = ch.dial(ctx); err != nil {
 				select {
@@ -244,18 +411,105 @@ func (ch *proton) release(conn *connect, err error) {
 	case <-ch.open:
 	default:
 	}
-	if err != nil || time.Since(conn.connectedAt) >= ch.opt.ConnMaxLifetime {
+	if conn.pending() {
+		// the caller does not wait for the server to end the canceled query
+		go func() {
+			conn.drain()
+			conn.close()
+		}()
+		return
+	}
+	if err != nil {
+		conn.close()
+		return
+	}
+	if time.Since(conn.connectedAt) >= ch.opt.ConnMaxLifetime {
+		atomic.AddInt64(&ch.stats.maxLifetimeClosed, 1)
 		conn.close()
 		return
 	}
+	conn.lastUsedIn = time.Now()
 	select {
 	case ch.idle <- conn:
 	default:
+		atomic.AddInt64(&ch.stats.maxIdleClosed, 1)
 		conn.close()
 	}
 }
 
+// reaper closes the expired idle connections and keeps MinIdleConns idle connections open.
+func (ch *proton) reaper() {
+	defer ch.wg.Done()
+	interval := time.Second
+	if ch.opt.ConnMaxIdleTime > 0 && ch.opt.ConnMaxIdleTime < interval {
+		interval = ch.opt.ConnMaxIdleTime
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ch.reap()
+		ch.warmUp()
+		select {
+		case <-ch.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ch *proton) reap() {
+	now := time.Now()
+	for i := len(ch.idle); i > 0; i-- {
+		var conn *connect
+		select {
+		case conn = <-ch.idle:
+		default:
+			return
+		}
+		if conn.isBad() || ch.expired(conn, now) {
+			conn.close()
+			continue
+		}
+		select {
+		case ch.idle <- conn:
+		default:
+			atomic.AddInt64(&ch.stats.maxIdleClosed, 1)
+			conn.close()
+		}
+	}
+}
+
+func (ch *proton) warmUp() {
+	for len(ch.idle) < ch.opt.MinIdleConns {
+		select {
+		case <-ch.done:
+			return
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), ch.opt.DialTimeout)
+		conn, err := ch.dial(ctx)
+		cancel()
+		if err != nil {
+			return
+		}
+		select {
+		case ch.idle <- conn:
+		default:
+			conn.close()
+			return
+		}
+	}
+}
+
 func (ch *proton) Close() error {
+	ch.closed.Do(func() {
+		close(ch.done)
+		if ch.stopObserver != nil {
+			ch.stopObserver()
+		}
+	})
+	ch.wg.Wait()
+	ch.hosts.close()
 	for {
 		select {
 		case c := <-ch.idle:
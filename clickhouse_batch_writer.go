@@ -0,0 +1,324 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+)
+
+type BatchWriterOptions struct {
+	// Context is used to prepare every batch, e.g. to pass settings or WithBatchRetry.
+	Context       context.Context
+	MaxRows       int           // default 10000
+	MaxBytes      int           // estimated size of the buffered rows, 0 means no limit
+	FlushInterval time.Duration // default 1 second
+	Parallelism   int           // concurrent flushes, default the MaxOpenConns of the pool
+	MaxPending    int           // flushes waiting for a free worker before Append blocks, default Parallelism
+	// OnError is called from a flush goroutine with the error and the number of rows that were lost,
+	// once the flush is no longer pending: it may call Flush.
+	OnError func(err error, rows int)
+}
+
+func (o *BatchWriterOptions) setDefaults(conn driver.Conn) {
+	if o.Context == nil {
+		o.Context = context.Background()
+	}
+	if o.MaxRows <= 0 {
+		o.MaxRows = 10000
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.Parallelism <= 0 {
+		if o.Parallelism = conn.Stats().MaxOpenConns; o.Parallelism <= 0 {
+			o.Parallelism = 1
+		}
+	}
+	if o.MaxPending <= 0 {
+		o.MaxPending = o.Parallelism
+	}
+}
+
+// BatchWriter buffers rows appended from any number of goroutines and inserts them
+// with PrepareBatch in the background, when MaxRows, MaxBytes or FlushInterval is reached.
+// When the flushes fall behind Append blocks until a flush completes.
+//
+// Rows are converted when they are flushed, so a row that does not match the stream
+// fails the whole flush it is part of; such errors are reported by OnError.
+type BatchWriter struct {
+	conn    driver.Conn
+	query   string
+	opts    BatchWriterOptions
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	err     error
+	rows    []batchWriterRow
+	size    int
+	closed  bool
+	pending int            // flushes queued or in progress
+	sending sync.WaitGroup // swapped buffers that are not in the queue yet
+	queue   chan []batchWriterRow
+	stop    chan struct{}
+	workers sync.WaitGroup
+}
+
+type batchWriterRow struct {
+	values []interface{}
+	// structValue is set for rows appended by AppendStruct
+	structValue interface{}
+}
+
+func NewBatchWriter(conn driver.Conn, query string, opts BatchWriterOptions) *BatchWriter {
+	opts.setDefaults(conn)
+	w := &BatchWriter{
+		conn:  conn,
+		query: query,
+		opts:  opts,
+		queue: make(chan []batchWriterRow, opts.MaxPending),
+		stop:  make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mutex)
+	w.workers.Add(opts.Parallelism + 1)
+	for i := 0; i < opts.Parallelism; i++ {
+		go w.worker()
+	}
+	go w.ticker()
+	return w
+}
+
+func (w *BatchWriter) Append(v ...interface{}) error {
+	values := make([]interface{}, len(v))
+	copy(values, v)
+	size := 0
+	for _, value := range values {
+		size += estimateSize(reflect.ValueOf(value))
+	}
+	return w.append(batchWriterRow{values: values}, size)
+}
+
+// AppendStruct copies the struct v points to, so v can be reused once it returns. The copy is
+// shallow: the slices, maps and pointers of the struct are read when the row is flushed.
+func (w *BatchWriter) AppendStruct(v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+		c := reflect.New(rv.Elem().Type())
+		c.Elem().Set(rv.Elem())
+		v = c.Interface()
+	}
+	return w.append(batchWriterRow{structValue: v}, estimateSize(reflect.ValueOf(v)))
+}
+
+func (w *BatchWriter) append(row batchWriterRow, size int) error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return ErrBatchWriterClosed
+	}
+	w.rows = append(w.rows, row)
+	w.size += size
+	if len(w.rows) < w.opts.MaxRows && (w.opts.MaxBytes <= 0 || w.size < w.opts.MaxBytes) {
+		w.mutex.Unlock()
+		return nil
+	}
+	rows := w.swap()
+	w.mutex.Unlock()
+	w.enqueue(rows)
+	return nil
+}
+
+// Flush sends the buffered rows and waits until every pending flush is done.
+// It returns the last error of the flushes that failed since the previous Flush.
+func (w *BatchWriter) Flush() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return ErrBatchWriterClosed
+	}
+	rows := w.swap()
+	w.mutex.Unlock()
+	w.enqueue(rows)
+	return w.wait()
+}
+
+// Close flushes the buffered rows, waits for the pending flushes and stops the writer.
+// It returns the last error of the flushes that failed since the previous Flush.
+func (w *BatchWriter) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return ErrBatchWriterClosed
+	}
+	w.closed = true
+	rows := w.swap()
+	w.mutex.Unlock()
+	w.enqueue(rows)
+	w.sending.Wait()
+	close(w.stop)
+	w.workers.Wait()
+	return w.wait()
+}
+
+// swap takes the buffered rows, the caller must hold the mutex and pass them to enqueue.
+func (w *BatchWriter) swap() []batchWriterRow {
+	rows := w.rows
+	w.rows, w.size = nil, 0
+	if len(rows) != 0 {
+		w.pending++
+		w.sending.Add(1)
+	}
+	return rows
+}
+
+func (w *BatchWriter) enqueue(rows []batchWriterRow) {
+	if len(rows) != 0 {
+		defer w.sending.Done()
+		w.queue <- rows
+	}
+}
+
+func (w *BatchWriter) wait() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for w.pending != 0 {
+		w.cond.Wait()
+	}
+	err := w.err
+	w.err = nil
+	return err
+}
+
+func (w *BatchWriter) worker() {
+	defer w.workers.Done()
+	for {
+		select {
+		case rows := <-w.queue:
+			w.flush(rows)
+		case <-w.stop:
+			for {
+				select {
+				case rows := <-w.queue:
+					w.flush(rows)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *BatchWriter) ticker() {
+	defer w.workers.Done()
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mutex.Lock()
+			if w.closed {
+				w.mutex.Unlock()
+				return
+			}
+			rows := w.swap()
+			w.mutex.Unlock()
+			w.enqueue(rows)
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *BatchWriter) flush(rows []batchWriterRow) {
+	err := w.send(rows)
+	w.mutex.Lock()
+	if err != nil {
+		w.err = err
+	}
+	w.pending--
+	w.cond.Broadcast()
+	w.mutex.Unlock()
+	if err != nil && w.opts.OnError != nil {
+		w.opts.OnError(err, len(rows))
+	}
+}
+
+func (w *BatchWriter) send(rows []batchWriterRow) error {
+	batch, err := w.conn.PrepareBatch(w.opts.Context, w.query)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		switch {
+		case row.structValue != nil:
+			err = batch.AppendStruct(row.structValue)
+		default:
+			err = batch.Append(row.values...)
+		}
+		if err != nil {
+			batch.Abort()
+			return err
+		}
+	}
+	return batch.Send()
+}
+
+// estimateSize is a rough estimate of the memory used by a value, it is only used for MaxBytes.
+func estimateSize(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 1
+		}
+		return estimateSize(v.Elem())
+	case reflect.String:
+		return v.Len()
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Len()
+		}
+		size := 0
+		for i := 0; i < v.Len(); i++ {
+			size += estimateSize(v.Index(i))
+		}
+		return size
+	case reflect.Map:
+		size := 0
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key()) + estimateSize(iter.Value())
+		}
+		return size
+	case reflect.Struct:
+		if v.NumField() == 0 || !v.Type().Field(0).IsExported() {
+			return int(v.Type().Size()) // time.Time, decimal.Decimal, etc.
+		}
+		size := 0
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				size += estimateSize(v.Field(i))
+			}
+		}
+		return size
+	}
+	return int(v.Type().Size())
+}
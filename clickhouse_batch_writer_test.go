@@ -0,0 +1,251 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+)
+
+type fakeBatchConn struct {
+	driver.Conn
+	mutex   sync.Mutex
+	batches []int
+	structs []interface{}
+	active  int32
+	max     int32
+	delay   time.Duration
+	fail    error
+}
+
+func (c *fakeBatchConn) Stats() driver.Stats {
+	return driver.Stats{MaxOpenConns: 4}
+}
+
+func (c *fakeBatchConn) PrepareBatch(ctx context.Context, query string) (driver.Batch, error) {
+	return &fakeBatch{conn: c}, nil
+}
+
+type fakeBatch struct {
+	driver.Batch
+	conn *fakeBatchConn
+	rows int
+}
+
+func (b *fakeBatch) Append(v ...interface{}) error {
+	b.rows++
+	return nil
+}
+
+func (b *fakeBatch) AppendStruct(v interface{}) error {
+	b.rows++
+	b.conn.mutex.Lock()
+	defer b.conn.mutex.Unlock()
+	b.conn.structs = append(b.conn.structs, v)
+	return nil
+}
+
+func (b *fakeBatch) Abort() error {
+	return nil
+}
+
+func (b *fakeBatch) Send() error {
+	active := atomic.AddInt32(&b.conn.active, 1)
+	defer atomic.AddInt32(&b.conn.active, -1)
+	for {
+		max := atomic.LoadInt32(&b.conn.max)
+		if active <= max || atomic.CompareAndSwapInt32(&b.conn.max, max, active) {
+			break
+		}
+	}
+	time.Sleep(b.conn.delay)
+	b.conn.mutex.Lock()
+	defer b.conn.mutex.Unlock()
+	if b.conn.fail != nil {
+		return b.conn.fail
+	}
+	b.conn.batches = append(b.conn.batches, b.rows)
+	return nil
+}
+
+func (c *fakeBatchConn) total() (total int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, rows := range c.batches {
+		total += rows
+	}
+	return total
+}
+
+func TestBatchWriterMaxRows(t *testing.T) {
+	conn := &fakeBatchConn{delay: 10 * time.Millisecond}
+	w := NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		MaxRows:       100,
+		FlushInterval: time.Hour,
+	})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				assert.NoError(t, w.Append(i, "value"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 8000, conn.total())
+	assert.Len(t, conn.batches, 80)
+	assert.LessOrEqual(t, atomic.LoadInt32(&conn.max), int32(4))
+	assert.Greater(t, atomic.LoadInt32(&conn.max), int32(1))
+	assert.Equal(t, ErrBatchWriterClosed, w.Append(1))
+	assert.Equal(t, ErrBatchWriterClosed, w.Close())
+}
+
+func TestBatchWriterAppendStructCopies(t *testing.T) {
+	type event struct {
+		ID   uint64
+		Name string
+	}
+	conn := &fakeBatchConn{}
+	w := NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		FlushInterval: time.Hour,
+	})
+	var e event
+	for i := 1; i <= 3; i++ {
+		e.ID, e.Name = uint64(i), "event"
+		assert.NoError(t, w.AppendStruct(&e))
+	}
+	e.Name = "reused"
+	assert.NoError(t, w.Close())
+	assert.Equal(t, []interface{}{
+		&event{ID: 1, Name: "event"},
+		&event{ID: 2, Name: "event"},
+		&event{ID: 3, Name: "event"},
+	}, conn.structs)
+}
+
+func TestBatchWriterMaxBytes(t *testing.T) {
+	conn := &fakeBatchConn{}
+	w := NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		MaxBytes:      1000,
+		FlushInterval: time.Hour,
+	})
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, w.AppendStruct(&struct {
+			Col1 string
+		}{
+			Col1: string(make([]byte, 500)),
+		}))
+	}
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, []int{2, 2, 2, 2, 2}, conn.batches)
+	assert.NoError(t, w.Close())
+}
+
+func TestBatchWriterInterval(t *testing.T) {
+	conn := &fakeBatchConn{}
+	w := NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		FlushInterval: 10 * time.Millisecond,
+	})
+	defer w.Close()
+	assert.NoError(t, w.Append(1))
+	assert.Eventually(t, func() bool {
+		return conn.total() == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBatchWriterOnError(t *testing.T) {
+	var (
+		lost int64
+		fail = errors.New("fail")
+		conn = &fakeBatchConn{fail: fail}
+	)
+	w := NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		MaxRows:       10,
+		FlushInterval: time.Hour,
+		OnError: func(err error, rows int) {
+			assert.Equal(t, fail, err)
+			atomic.AddInt64(&lost, int64(rows))
+		},
+	})
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, w.Append(i))
+	}
+	assert.Equal(t, fail, w.Close())
+	assert.Equal(t, int64(25), atomic.LoadInt64(&lost))
+}
+
+func TestBatchWriterFlushInOnError(t *testing.T) {
+	var (
+		fail = errors.New("fail")
+		conn = &fakeBatchConn{fail: fail}
+		errs = make(chan error, 1)
+	)
+	var w *BatchWriter
+	w = NewBatchWriter(conn, "INSERT INTO t", BatchWriterOptions{
+		MaxRows:       10,
+		FlushInterval: time.Hour,
+		OnError: func(err error, rows int) {
+			errs <- w.Flush()
+		},
+	})
+	assert.NoError(t, w.Append(1))
+	flushErr := w.Flush()
+	select {
+	case err := <-errs:
+		// the error is returned once, by the Flush that returns first
+		assert.ElementsMatch(t, []error{fail, nil}, []error{flushErr, err})
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush called from OnError did not return")
+	}
+
+	conn.mutex.Lock()
+	conn.fail = nil
+	conn.mutex.Unlock()
+	assert.NoError(t, w.Append(2))
+	assert.NoError(t, w.Flush(), "the error of a previous flush is not returned again")
+	assert.NoError(t, w.Close())
+	assert.Equal(t, 1, conn.total())
+}
+
+func TestEstimateSize(t *testing.T) {
+	var (
+		str = "value"
+		ptr *string
+	)
+	for expected, v := range map[int]interface{}{
+		8:  int64(1),
+		5:  str,
+		6:  []byte("value1"),
+		15: []string{"a", "bb", "cccccccccccc"},
+		1:  ptr,
+		13: map[string]int64{"key12": 1},
+	} {
+		assert.Equal(t, expected, estimateSize(reflect.ValueOf(v)), "%T", v)
+	}
+}
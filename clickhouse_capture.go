@@ -0,0 +1,175 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	protonio "github.com/timeplus-io/proton-go-driver/v2/lib/io"
+)
+
+// ReplayDialer plays back the connections of a capture written with Options.Capture. Every dial
+// returns the next recorded connection, which reads what the server sent and discards the writes,
+// so the driver decodes the same bytes as the captured session. Set its DialContext in Options,
+// with the options of the session: the same compression, and no health checks or idle connections
+// that would dial in another order. The reads and the writes of a connection fail with
+// os.ErrDeadlineExceeded once its deadline has passed.
+type ReplayDialer struct {
+	mu       sync.Mutex
+	sessions []*replaySession
+}
+
+type replaySession struct {
+	addr string
+	read bytes.Buffer
+}
+
+func NewReplayDialer(r io.Reader) (*ReplayDialer, error) {
+	capture, err := protonio.NewCaptureReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		dialer   ReplayDialer
+		sessions = make(map[uint64]*replaySession)
+	)
+	for {
+		record, err := capture.Next()
+		switch {
+		case err == io.EOF:
+			return &dialer, nil
+		case err != nil:
+			return nil, err
+		}
+		switch record.Kind {
+		case protonio.RecordDial:
+			session := &replaySession{addr: string(record.Data)}
+			sessions[record.Session] = session
+			dialer.sessions = append(dialer.sessions, session)
+		case protonio.RecordWireRead:
+			if session, ok := sessions[record.Session]; ok {
+				session.read.Write(record.Data)
+			}
+		}
+	}
+}
+
+// Remaining is the number of recorded connections not dialed yet.
+func (d *ReplayDialer) Remaining() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.sessions)
+}
+
+func (d *ReplayDialer) DialContext(ctx context.Context, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.sessions) == 0 {
+		return nil, errors.New("proton [replay]: no more recorded connections")
+	}
+	session := d.sessions[0]
+	d.sessions = d.sessions[1:]
+	return &replayConn{
+		addr: replayAddr(session.addr),
+		read: &session.read,
+	}, nil
+}
+
+// replayConn is safe for concurrent use, like a net.Conn: the pool may close it while a
+// query reads from it.
+type replayConn struct {
+	addr          replayAddr
+	mu            sync.Mutex
+	read          *bytes.Buffer
+	closed        bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.closed:
+		return 0, net.ErrClosed
+	case expired(c.readDeadline):
+		return 0, os.ErrDeadlineExceeded
+	}
+	return c.read.Read(p)
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.closed:
+		return 0, net.ErrClosed
+	case expired(c.writeDeadline):
+		return 0, os.ErrDeadlineExceeded
+	}
+	return len(p), nil
+}
+
+func (c *replayConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *replayConn) LocalAddr() net.Addr  { return replayAddr("replay") }
+func (c *replayConn) RemoteAddr() net.Addr { return c.addr }
+
+func (c *replayConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline, c.writeDeadline = t, t
+	return nil
+}
+
+func (c *replayConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+func (c *replayConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// expired reports whether the deadline t is set and passed, the recorded bytes are all
+// available at once so a read never waits for it.
+func expired(t time.Time) bool {
+	return !t.IsZero() && !time.Now().Before(t)
+}
+
+type replayAddr string
+
+func (a replayAddr) Network() string { return "replay" }
+func (a replayAddr) String() string  { return string(a) }
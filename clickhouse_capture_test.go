@@ -0,0 +1,224 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protonio "github.com/timeplus-io/proton-go-driver/v2/lib/io"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func captureQuery(t *testing.T, opt *Options) []string {
+	conn, err := Open(opt)
+	require.NoError(t, err)
+	defer conn.Close()
+	rows, err := conn.Query(context.Background(), "SELECT s FROM example")
+	require.NoError(t, err)
+	var values []string
+	for rows.Next() {
+		var s string
+		require.NoError(t, rows.Scan(&s))
+		values = append(values, s)
+	}
+	require.NoError(t, rows.Err())
+	return values
+}
+
+func TestCaptureReplay(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		var block proto.Block
+		block.AddColumn("s", "string")
+		block.Append("a")
+		block.Append("b")
+		return w.Data(&block)
+	}))
+	var capture bytes.Buffer
+	expected := captureQuery(t, &Options{
+		Addr:        []string{srv.Addr()},
+		Compression: &Compression{Method: CompressionLZ4},
+		Capture:     &capture,
+	})
+	srv.Close()
+	require.Equal(t, []string{"a", "b"}, expected)
+
+	reader, err := protonio.NewCaptureReader(bytes.NewReader(capture.Bytes()))
+	require.NoError(t, err)
+	var (
+		sessions = make(map[uint64]bool)
+		packets  = make(map[protonio.RecordKind][]byte)
+		kinds    = make(map[protonio.RecordKind]int)
+	)
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.False(t, record.Time.IsZero())
+		sessions[record.Session] = true
+		kinds[record.Kind]++
+		if record.Kind == protonio.RecordPacketRead || record.Kind == protonio.RecordPacketWrite {
+			packets[record.Kind] = append(packets[record.Kind], record.Data...)
+		}
+	}
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, 1, kinds[protonio.RecordDial])
+	for _, kind := range []protonio.RecordKind{protonio.RecordWireRead, protonio.RecordWireWrite, protonio.RecordDataRead, protonio.RecordDataWrite} {
+		assert.NotZero(t, kinds[kind], kind.String())
+	}
+	assert.Equal(t, []byte{proto.ClientHello, proto.ClientQuery, proto.ClientData}, packets[protonio.RecordPacketWrite])
+	assert.Equal(t, []byte{proto.ServerHello, proto.ServerData, proto.ServerEndOfStream}, packets[protonio.RecordPacketRead])
+
+	replay, err := NewReplayDialer(bytes.NewReader(capture.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, expected, captureQuery(t, &Options{
+		Addr:        []string{srv.Addr()},
+		Compression: &Compression{Method: CompressionLZ4},
+		DialContext: replay.DialContext,
+	}))
+	assert.Zero(t, replay.Remaining())
+	_, err = replay.DialContext(context.Background(), srv.Addr())
+	assert.Error(t, err)
+}
+
+func TestCaptureReaderInvalid(t *testing.T) {
+	_, err := protonio.NewCaptureReader(bytes.NewReader([]byte("not a capture")))
+	assert.Error(t, err)
+	var capture bytes.Buffer
+	protonio.NewCaptureWriter(&capture).Session("127.0.0.1:8463")
+	reader, err := protonio.NewCaptureReader(bytes.NewReader(capture.Bytes()[:capture.Len()-2]))
+	require.NoError(t, err)
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestCaptureRedactsPassword(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		var block proto.Block
+		block.AddColumn("s", "string")
+		block.Append("a")
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	var capture bytes.Buffer
+	captureQuery(t, &Options{
+		Addr:    []string{srv.Addr()},
+		Auth:    Auth{Password: "p4ssw0rd-secret"},
+		Capture: &capture,
+	})
+	assert.False(t, bytes.Contains(capture.Bytes(), []byte("p4ssw0rd-secret")))
+	assert.True(t, bytes.Contains(capture.Bytes(), []byte("***************")))
+
+	// the replay does not need the password
+	replay, err := NewReplayDialer(bytes.NewReader(capture.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, captureQuery(t, &Options{
+		Addr:        []string{srv.Addr()},
+		DialContext: replay.DialContext,
+	}))
+}
+
+func TestCaptureShortPassword(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		var block proto.Block
+		block.AddColumn("s", "string")
+		block.Append("a")
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	var capture bytes.Buffer
+	captureQuery(t, &Options{
+		Addr:    []string{srv.Addr()},
+		Auth:    Auth{Password: "e"},
+		Capture: &capture,
+	})
+	// only the password of the hello is masked, not the same bytes elsewhere
+	reader, err := protonio.NewCaptureReader(bytes.NewReader(capture.Bytes()))
+	require.NoError(t, err)
+	var wire, data []byte
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch record.Kind {
+		case protonio.RecordWireWrite:
+			wire = append(wire, record.Data...)
+		case protonio.RecordDataWrite:
+			data = append(data, record.Data...)
+		}
+	}
+	assert.Equal(t, data, wire)
+	assert.True(t, bytes.Contains(wire, []byte("SELECT s FROM example")))
+	assert.Equal(t, 1, bytes.Count(wire, []byte("*")))
+	assert.True(t, bytes.Contains(wire, []byte("default\x01*")), "%q", wire)
+}
+
+func TestReplayConnDeadline(t *testing.T) {
+	var capture bytes.Buffer
+	session := protonio.NewCaptureWriter(&capture).Session("127.0.0.1:8463")
+	stream := protonio.NewStream(bytes.NewBufferString("recorded"))
+	stream.Capture(session)
+	p := make([]byte, 4)
+	_, err := stream.Read(p)
+	require.NoError(t, err)
+	replay, err := NewReplayDialer(bytes.NewReader(capture.Bytes()))
+	require.NoError(t, err)
+	conn, err := replay.DialContext(context.Background(), "127.0.0.1:8463")
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetDeadline(time.Now().Add(-time.Second)))
+	_, err = conn.Read(p)
+	var netErr net.Error
+	if assert.True(t, errors.As(err, &netErr)) {
+		assert.True(t, netErr.Timeout())
+	}
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	_, err = conn.Write(p)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Minute)))
+	n, err := conn.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, "reco", string(p[:n]))
+
+	// the pool may close a connection while it is read
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn.Read(p)
+	}()
+	require.NoError(t, conn.Close())
+	wg.Wait()
+	_, err = conn.Read(p)
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
@@ -0,0 +1,205 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+)
+
+type (
+	StreamKind        = driver.StreamKind
+	StreamColumn      = driver.StreamColumn
+	StreamDescription = driver.StreamDescription
+	StreamDescriber   = driver.StreamDescriber
+)
+
+const (
+	StreamKindAppend           = driver.StreamKindAppend
+	StreamKindVersionedKV      = driver.StreamKindVersionedKV
+	StreamKindChangelogKV      = driver.StreamKindChangelogKV
+	StreamKindChangelog        = driver.StreamKindChangelog
+	StreamKindExternal         = driver.StreamKindExternal
+	StreamKindView             = driver.StreamKindView
+	StreamKindMaterializedView = driver.StreamKindMaterializedView
+	StreamKindOther            = driver.StreamKindOther
+)
+
+var _ (driver.StreamDescriber) = (*proton)(nil)
+
+func (ch *proton) DescribeStream(ctx context.Context, name string) (*StreamDescription, error) {
+	database, name := splitStreamName(name)
+	query := lexer.QuoteIdentifier(name)
+	if len(database) != 0 {
+		query = lexer.QuoteIdentifier(database) + "." + query
+	}
+	rows, err := ch.Query(ctx, "DESCRIBE "+query)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := describeColumns(rows)
+	if err != nil {
+		return nil, err
+	}
+	streams, err := ch.listStreams(ctx, database, name)
+	if err != nil {
+		return nil, err
+	}
+	description := StreamDescription{
+		Database: database,
+		Name:     name,
+	}
+	if len(streams) != 0 {
+		description = streams[0]
+	}
+	description.Columns = columns
+	return &description, nil
+}
+
+func (ch *proton) ListStreams(ctx context.Context, database string) ([]StreamDescription, error) {
+	return ch.listStreams(ctx, database, "")
+}
+
+// listStreams reads system.tables, the inner streams of the materialized views are left out.
+func (ch *proton) listStreams(ctx context.Context, database, name string) ([]StreamDescription, error) {
+	var (
+		query = "SELECT database, name, engine, engine_full, comment FROM system.tables WHERE "
+		args  []interface{}
+	)
+	if len(database) != 0 {
+		query += "database = @database"
+		args = append(args, Named("database", database))
+	} else {
+		query += "database = currentDatabase()"
+	}
+	if len(name) != 0 {
+		query += " AND name = @name"
+		args = append(args, Named("name", name))
+	}
+	rows, err := ch.Query(ctx, query+" AND NOT startsWith(name, '.inner') ORDER BY name", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var streams []StreamDescription
+	for rows.Next() {
+		var (
+			stream     StreamDescription
+			engineFull string
+		)
+		if err := rows.Scan(&stream.Database, &stream.Name, &stream.Engine, &engineFull, &stream.Comment); err != nil {
+			return nil, err
+		}
+		stream.Kind = streamKind(stream.Engine, engineFull)
+		streams = append(streams, stream)
+	}
+	return streams, rows.Err()
+}
+
+// describeColumns reads the result of DESCRIBE by column name, the columns it has depend on the server.
+func describeColumns(rows driver.Rows) ([]StreamColumn, error) {
+	defer rows.Close()
+	var (
+		names  = rows.Columns()
+		types  = rows.ColumnTypes()
+		values = make([]string, len(names))
+		dest   = make([]interface{}, len(names))
+	)
+	for i := range names {
+		switch names[i] {
+		case "name", "type", "default_type", "default_expression", "comment", "codec_expression":
+			dest[i] = &values[i]
+		default:
+			dest[i] = reflect.New(types[i].ScanType()).Interface()
+		}
+	}
+	var columns []StreamColumn
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		var c StreamColumn
+		for i, name := range names {
+			switch name {
+			case "name":
+				c.Name = values[i]
+			case "type":
+				c.Type = column.Type(values[i])
+			case "default_type":
+				c.DefaultKind = values[i]
+			case "default_expression":
+				c.DefaultExpression = values[i]
+			case "comment":
+				c.Comment = values[i]
+			case "codec_expression":
+				c.Codec = values[i]
+			}
+		}
+		c.Column, _ = c.Type.Column()
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+var streamModeRe = regexp.MustCompile(`(?i)\bmode\s*=\s*'([a-z_]+)'`)
+
+func streamKind(engine, engineFull string) StreamKind {
+	switch engine {
+	case "Stream":
+		if match := streamModeRe.FindStringSubmatch(engineFull); match != nil {
+			switch mode := StreamKind(strings.ToLower(match[1])); mode {
+			case StreamKindAppend, StreamKindVersionedKV, StreamKindChangelogKV, StreamKindChangelog:
+				return mode
+			}
+			return StreamKindOther
+		}
+		return StreamKindAppend
+	case "ExternalStream", "ExternalTable":
+		return StreamKindExternal
+	case "View":
+		return StreamKindView
+	case "MaterializedView":
+		return StreamKindMaterializedView
+	}
+	return StreamKindOther
+}
+
+// splitStreamName splits a name qualified with its database, at its first dot unless its parts
+// are quoted: `db`.`a.b` is the stream a.b of the database db.
+func splitStreamName(name string) (database, stream string) {
+	switch tokens := lexer.Tokenize(name); {
+	case len(tokens) == 1 && tokens[0].Kind == lexer.QuotedIdentifier:
+		return "", lexer.UnquoteIdentifier(tokens[0].Text)
+	case len(tokens) == 3 && tokens[1].Text == "." && isIdentifier(tokens[0]) && isIdentifier(tokens[2]):
+		return lexer.UnquoteIdentifier(tokens[0].Text), lexer.UnquoteIdentifier(tokens[2].Text)
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func isIdentifier(token lexer.Token) bool {
+	return token.Kind == lexer.Word || token.Kind == lexer.QuotedIdentifier
+}
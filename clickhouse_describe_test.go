@@ -0,0 +1,117 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestStreamKind(t *testing.T) {
+	for _, tc := range []struct {
+		engine, engineFull string
+		kind               StreamKind
+	}{
+		{"Stream", "Stream(1, 1, rand())", StreamKindAppend},
+		{"Stream", "Stream(1, 1, rand()) SETTINGS mode = 'versioned_kv'", StreamKindVersionedKV},
+		{"Stream", "Stream(1, 1, rand()) SETTINGS mode='changelog_kv', shards = 1", StreamKindChangelogKV},
+		{"Stream", "Stream(1, 1, rand()) SETTINGS mode = 'unknown'", StreamKindOther},
+		{"ExternalStream", "ExternalStream SETTINGS type = 'kafka'", StreamKindExternal},
+		{"View", "View", StreamKindView},
+		{"MaterializedView", "MaterializedView", StreamKindMaterializedView},
+		{"Memory", "Memory", StreamKindOther},
+	} {
+		assert.Equal(t, tc.kind, streamKind(tc.engine, tc.engineFull), tc.engineFull)
+	}
+}
+
+func TestSplitStreamName(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"events":           {"", "events"},
+		"db.events":        {"db", "events"},
+		"db.a.b":           {"db", "a.b"},
+		"`a.b`":            {"", "a.b"},
+		"`db`.`a.b`":       {"db", "a.b"},
+		"db.`a.b`":         {"db", "a.b"},
+		"`my.db`.events":   {"my.db", "events"},
+		"`we\\`ird`.`a.b`": {"we`ird", "a.b"},
+	} {
+		database, stream := splitStreamName(name)
+		assert.Equal(t, expected, [2]string{database, stream}, name)
+	}
+}
+
+func TestDescribeStream(t *testing.T) {
+	var queries []string
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		queries = append(queries, q.Body)
+		var block proto.Block
+		switch {
+		case strings.HasPrefix(q.Body, "DESCRIBE"):
+			for _, name := range []string{"name", "type", "default_type", "default_expression", "comment", "codec_expression", "ttl_expression"} {
+				block.AddColumn(name, "string")
+			}
+			block.Append("id", "uint64", "", "", "the id", "", "")
+			block.Append("tags", "array(nullable(string))", "", "", "", "CODEC(ZSTD(1))", "")
+			block.Append("_tp_time", "datetime64(3, 'UTC')", "DEFAULT", "now64(3, 'UTC')", "", "", "")
+		default:
+			for _, name := range []string{"database", "name", "engine", "engine_full", "comment"} {
+				block.AddColumn(name, "string")
+			}
+			block.Append("db", "events", "Stream", "Stream(1, 1, rand()) SETTINGS mode = 'versioned_kv'", "all the events")
+		}
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+	description, err := conn.(StreamDescriber).DescribeStream(context.Background(), "db.events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DESCRIBE `db`.`events`",
+		"SELECT database, name, engine, engine_full, comment FROM system.tables WHERE database = 'db' AND name = 'events' AND NOT startsWith(name, '.inner') ORDER BY name",
+	}, queries)
+	assert.Equal(t, "db", description.Database)
+	assert.Equal(t, "events", description.Name)
+	assert.Equal(t, StreamKindVersionedKV, description.Kind)
+	assert.Equal(t, "Stream", description.Engine)
+	assert.Equal(t, "all the events", description.Comment)
+	require.Len(t, description.Columns, 3)
+	assert.Equal(t, StreamColumn{
+		Name:    "id",
+		Type:    "uint64",
+		Column:  description.Columns[0].Column,
+		Comment: "the id",
+	}, description.Columns[0])
+	tags := description.Columns[1]
+	assert.Equal(t, "CODEC(ZSTD(1))", tags.Codec)
+	if array, ok := tags.Column.(*column.Array); assert.True(t, ok) {
+		_, ok := array.Base().(*column.Nullable)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, "DEFAULT", description.Columns[2].DefaultKind)
+	assert.Equal(t, "now64(3, 'UTC')", description.Columns[2].DefaultExpression)
+}
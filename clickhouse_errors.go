@@ -0,0 +1,80 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+type ErrorCategory = proto.ErrorCategory
+
+// The sentinel errors matched by errors.Is on an Exception, or on any error wrapping one.
+// The Is helpers also classify the errors of the driver and of the network.
+var (
+	ErrRetryable     = proto.ErrRetryable
+	ErrTimeout       = proto.ErrTimeout
+	ErrAuthFailure   = proto.ErrAuthFailure
+	ErrSyntax        = proto.ErrSyntax
+	ErrTableNotFound = proto.ErrTableNotFound
+)
+
+// IsRetryable reports whether the call that returned err may succeed if it is made again:
+// the connection was lost, the pool or the server was busy, or the server returned a
+// retryable exception. Canceled calls are not retryable.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrRetryable), errors.Is(err, ErrAcquireConnTimeout), errors.Is(err, sqldriver.ErrBadConn):
+		return true
+	}
+	return isConnError(err)
+}
+
+// IsTimeout reports whether err is a timeout of the server, the network, the pool or the context.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrTimeout), errors.Is(err, ErrAcquireConnTimeout), errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	}
+	return false
+}
+
+func IsAuthFailure(err error) bool {
+	return errors.Is(err, ErrAuthFailure)
+}
+
+func IsSyntaxError(err error) bool {
+	return errors.Is(err, ErrSyntax)
+}
+
+func IsTableNotFound(err error) bool {
+	return errors.Is(err, ErrTableNotFound)
+}
@@ -0,0 +1,106 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+func TestErrorClassification(t *testing.T) {
+	var (
+		timeout = &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+		wrapped = func(code int32) error {
+			return &OpError{Op: "Send", Err: fmt.Errorf("send: %w", &Exception{Code: code})}
+		}
+	)
+	for _, test := range []struct {
+		name                                            string
+		err                                             error
+		retryable, timeout, auth, syntax, tableNotFound bool
+	}{
+		{name: "nil"},
+		{name: "network error", err: wrapped(210), retryable: true},
+		{name: "too many queries", err: wrapped(202), retryable: true},
+		{name: "query timeout", err: wrapped(159), timeout: true},
+		{name: "socket timeout", err: wrapped(209), retryable: true, timeout: true},
+		{name: "wrong password", err: wrapped(193), auth: true},
+		{name: "syntax error", err: wrapped(62), syntax: true},
+		{name: "unknown stream", err: wrapped(60), tableNotFound: true},
+		{name: "unknown code", err: wrapped(123456)},
+		{name: "connection lost", err: fmt.Errorf("read: %w", io.EOF), retryable: true},
+		{name: "net timeout", err: timeout, retryable: true, timeout: true},
+		{name: "acquire timeout", err: ErrAcquireConnTimeout, retryable: true, timeout: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), timeout: true},
+		{name: "column", err: &OpError{Op: "Append", Err: errors.New("converting string to int64 is unsupported")}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.retryable, IsRetryable(test.err), "IsRetryable")
+			assert.Equal(t, test.timeout, IsTimeout(test.err), "IsTimeout")
+			assert.Equal(t, test.auth, IsAuthFailure(test.err), "IsAuthFailure")
+			assert.Equal(t, test.syntax, IsSyntaxError(test.err), "IsSyntaxError")
+			assert.Equal(t, test.tableNotFound, IsTableNotFound(test.err), "IsTableNotFound")
+		})
+	}
+}
+
+func TestNestedException(t *testing.T) {
+	err := &OpError{
+		Op: "Query",
+		Err: &Exception{
+			Code:    1000,
+			Message: "outer",
+			Nested: []Exception{
+				{Code: 210, Message: "network"},
+				{Code: 62, Message: "syntax"},
+			},
+		},
+	}
+	var exception *Exception
+	if assert.ErrorAs(t, err, &exception) {
+		assert.Equal(t, int32(1000), exception.Code)
+		assert.Equal(t, "POCO_EXCEPTION", exception.CodeName())
+		assert.Equal(t, proto.CategoryOther, exception.Category())
+		cause, ok := exception.Unwrap().(*Exception)
+		if assert.True(t, ok) {
+			assert.Equal(t, int32(210), cause.Code)
+			assert.Len(t, cause.Nested, 1)
+		}
+	}
+	// only the outer exception is classified
+	assert.False(t, IsRetryable(err))
+	assert.False(t, IsSyntaxError(err))
+	assert.False(t, IsAuthFailure(err))
+	outer := &Exception{
+		Code:   209,
+		Nested: []Exception{{Code: 62, Message: "syntax"}},
+	}
+	assert.True(t, IsRetryable(outer))
+	assert.False(t, IsSyntaxError(outer))
+	var block error = &proto.BlockError{Op: "Decode", Err: io.ErrUnexpectedEOF}
+	assert.ErrorIs(t, block, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,279 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+)
+
+type HostStats = driver.HostStats
+
+// HostSelector decides in which order the hosts are tried when a new connection is opened.
+// Select gets the healthy hosts in the order of Options.Addr and returns the addresses to dial,
+// connID is the sequence number of the connection. Hosts left out are not dialed.
+type HostSelector interface {
+	Select(hosts []HostStats, connID int) []string
+}
+
+type HostSelectorFunc func(hosts []HostStats, connID int) []string
+
+func (f HostSelectorFunc) Select(hosts []HostStats, connID int) []string {
+	return f(hosts, connID)
+}
+
+func hostSelector(strategy ConnOpenStrategy) HostSelector {
+	switch strategy {
+	case ConnOpenRoundRobin:
+		return HostSelectorFunc(selectRoundRobin)
+	case ConnOpenRandom:
+		return HostSelectorFunc(selectRandom)
+	case ConnOpenLeastConnections:
+		return HostSelectorFunc(selectLeastConnections)
+	}
+	return HostSelectorFunc(selectInOrder)
+}
+
+func selectInOrder(hosts []HostStats, _ int) []string {
+	addrs := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		addrs = append(addrs, h.Addr)
+	}
+	return addrs
+}
+
+func selectRoundRobin(hosts []HostStats, connID int) []string {
+	addrs := make([]string, 0, len(hosts))
+	for i := range hosts {
+		addrs = append(addrs, hosts[(connID+i)%len(hosts)].Addr)
+	}
+	return addrs
+}
+
+func selectRandom(hosts []HostStats, _ int) []string {
+	addrs := selectInOrder(hosts, 0)
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	return addrs
+}
+
+func selectLeastConnections(hosts []HostStats, _ int) []string {
+	sorted := append([]HostStats(nil), hosts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Open < sorted[j].Open
+	})
+	return selectInOrder(sorted, 0)
+}
+
+type host struct {
+	addr string
+	open int64
+
+	mu           sync.Mutex
+	failures     int
+	lastError    error
+	lastCheck    time.Time
+	ejectedUntil time.Time
+}
+
+func (h *host) fail(err error, ejectFor time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures++
+	h.lastError = err
+	h.ejectedUntil = time.Now().Add(ejectFor)
+}
+
+// succeed resets the failures of the host and reports whether it had any.
+func (h *host) succeed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	failed := h.failures != 0
+	h.failures = 0
+	h.lastError = nil
+	h.ejectedUntil = time.Time{}
+	return failed
+}
+
+func (h *host) stats(now time.Time) HostStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return HostStats{
+		Addr:         h.addr,
+		Healthy:      !now.Before(h.ejectedUntil),
+		Open:         int(atomic.LoadInt64(&h.open)),
+		Failures:     h.failures,
+		LastError:    h.lastError,
+		LastCheck:    h.lastCheck,
+		EjectedUntil: h.ejectedUntil,
+	}
+}
+
+// hosts keeps track of the health of Options.Addr. A host is ejected for HostEjectTime
+// after a failed dial or health check and is let back in by a successful health check
+// or once the time is up.
+type hosts struct {
+	opt      *Options
+	list     []*host
+	byAddr   map[string]*host
+	selector HostSelector
+	done     chan struct{}
+	closed   sync.Once
+	wg       sync.WaitGroup
+}
+
+func newHosts(opt *Options) *hosts {
+	h := &hosts{
+		opt:      opt,
+		byAddr:   make(map[string]*host, len(opt.Addr)),
+		selector: opt.HostSelector,
+		done:     make(chan struct{}),
+	}
+	if h.selector == nil {
+		h.selector = hostSelector(opt.ConnOpenStrategy)
+	}
+	for _, addr := range opt.Addr {
+		if _, found := h.byAddr[addr]; found {
+			continue
+		}
+		host := &host{addr: addr}
+		h.list, h.byAddr[addr] = append(h.list, host), host
+	}
+	if opt.HealthCheckInterval > 0 && len(h.list) != 0 {
+		h.wg.Add(1)
+		go h.run(opt.HealthCheckInterval)
+	}
+	return h
+}
+
+// candidates returns the hosts to dial: the healthy ones ordered by the selector,
+// followed by the ejected ones as a last resort.
+func (h *hosts) candidates(connID int) []*host {
+	var (
+		now     = time.Now()
+		healthy = make([]HostStats, 0, len(h.list))
+		ejected []*host
+	)
+	for _, host := range h.list {
+		switch stats := host.stats(now); {
+		case stats.Healthy:
+			healthy = append(healthy, stats)
+		default:
+			ejected = append(ejected, host)
+		}
+	}
+	var candidates []*host
+	if len(healthy) != 0 {
+		for _, addr := range h.selector.Select(healthy, connID) {
+			if host, found := h.byAddr[addr]; found {
+				candidates = append(candidates, host)
+			}
+		}
+	}
+	return append(candidates, ejected...)
+}
+
+func (h *hosts) dial(ctx context.Context, connID int) (conn *connect, err error) {
+	err = errors.New("proton: no hosts to connect to")
+	for _, host := range h.candidates(connID) {
+		if conn, err = dial(ctx, host.addr, connID, h.opt); err == nil {
+			if host.succeed() {
+				h.opt.log(LogInfo, "host is back", "addr", host.addr)
+			}
+			atomic.AddInt64(&host.open, 1)
+			conn.host = host
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		host.fail(err, h.opt.HostEjectTime)
+		h.opt.log(LogWarn, "dial failed, host ejected", "addr", host.addr, "error", err, "eject_time", h.opt.HostEjectTime)
+	}
+	return nil, err
+}
+
+func (h *hosts) run(interval time.Duration) {
+	defer h.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			h.check()
+		}
+	}
+}
+
+// check probes every host over a new connection.
+func (h *hosts) check() {
+	var wg sync.WaitGroup
+	for i := range h.list {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.checkHost(h.list[i])
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (h *hosts) checkHost(host *host) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.opt.DialTimeout)
+	defer cancel()
+	err := probe(ctx, host.addr, h.opt)
+	host.mu.Lock()
+	host.lastCheck = time.Now()
+	host.mu.Unlock()
+	if err != nil {
+		host.fail(err, h.opt.HostEjectTime)
+		h.opt.log(LogWarn, "health check failed, host ejected", "addr", host.addr, "error", err, "eject_time", h.opt.HostEjectTime)
+		return
+	}
+	if host.succeed() {
+		h.opt.log(LogInfo, "host is back", "addr", host.addr)
+	}
+}
+
+func (h *hosts) stats() []HostStats {
+	var (
+		now   = time.Now()
+		stats = make([]HostStats, 0, len(h.list))
+	)
+	for _, host := range h.list {
+		stats = append(stats, host.stats(now))
+	}
+	return stats
+}
+
+func (h *hosts) close() {
+	h.closed.Do(func() {
+		close(h.done)
+	})
+	h.wg.Wait()
+}
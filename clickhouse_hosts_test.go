@@ -0,0 +1,177 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestHostSelectors(t *testing.T) {
+	hosts := []HostStats{
+		{Addr: "a", Open: 3},
+		{Addr: "b", Open: 1},
+		{Addr: "c", Open: 2},
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, selectInOrder(hosts, 5))
+	assert.Equal(t, []string{"b", "c", "a"}, selectRoundRobin(hosts, 1))
+	assert.Equal(t, []string{"c", "a", "b"}, selectRoundRobin(hosts, 5))
+	assert.Equal(t, []string{"b", "c", "a"}, selectLeastConnections(hosts, 0))
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, selectRandom(hosts, 0))
+	assert.Equal(t, "a", hosts[0].Addr, "selectors must not reorder their input")
+}
+
+// closedAddr returns an address nothing listens on.
+func closedAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return addr
+}
+
+func TestHostsEjectOnDialError(t *testing.T) {
+	var (
+		first, second = closedAddr(t), closedAddr(t)
+		selected      [][]string
+		opt           = &Options{
+			Addr: []string{first, second, first},
+			HostSelector: HostSelectorFunc(func(hosts []HostStats, connID int) []string {
+				addrs := selectInOrder(hosts, connID)
+				selected = append(selected, addrs)
+				return addrs
+			}),
+			HostEjectTime: time.Minute,
+		}
+	)
+	opt.setDefaults()
+	h := newHosts(opt)
+	defer h.close()
+
+	_, err := h.dial(context.Background(), 1)
+	require.Error(t, err)
+	assert.Equal(t, [][]string{{first, second}}, selected)
+	stats := h.stats()
+	if assert.Len(t, stats, 2) {
+		for _, s := range stats {
+			assert.False(t, s.Healthy)
+			assert.Equal(t, 1, s.Failures)
+			assert.Error(t, s.LastError)
+			assert.True(t, s.EjectedUntil.After(time.Now()))
+		}
+	}
+	// ejected hosts are still tried as a last resort, without asking the selector
+	_, err = h.dial(context.Background(), 2)
+	require.Error(t, err)
+	assert.Len(t, selected, 1)
+	for _, s := range h.stats() {
+		assert.Equal(t, 2, s.Failures)
+	}
+
+	h.list[0].succeed()
+	candidates := h.candidates(3)
+	if assert.Len(t, candidates, 2) {
+		assert.Equal(t, first, candidates[0].addr)
+		assert.Equal(t, second, candidates[1].addr)
+	}
+}
+
+func TestHostsDialCanceled(t *testing.T) {
+	opt := &Options{
+		Addr: []string{"127.0.0.1:8463"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			return nil, errors.New("canceled")
+		},
+	}
+	opt.setDefaults()
+	h := newHosts(opt)
+	defer h.close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := h.dial(ctx, 1)
+	require.Error(t, err)
+	assert.True(t, h.stats()[0].Healthy, "a canceled dial must not eject the host")
+}
+
+func TestHostsHealthCheck(t *testing.T) {
+	opt := &Options{
+		Addr:                []string{closedAddr(t)},
+		HealthCheckInterval: 10 * time.Millisecond,
+		HostEjectTime:       time.Minute,
+	}
+	opt.setDefaults()
+	h := newHosts(opt)
+	require.Eventually(t, func() bool {
+		s := h.stats()[0]
+		return !s.Healthy && !s.LastCheck.IsZero()
+	}, time.Second, 5*time.Millisecond)
+	h.close()
+	h.close()
+}
+
+type closeCountConn struct {
+	net.Conn
+	closed *int32
+}
+
+func (c *closeCountConn) Close() error {
+	atomic.AddInt32(c.closed, 1)
+	return c.Conn.Close()
+}
+
+func TestHandshakeErrorClosesConn(t *testing.T) {
+	var closed int32
+	opt := &Options{
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			server.Close() // the handshake fails with EOF
+			return &closeCountConn{Conn: client, closed: &closed}, nil
+		},
+	}
+	opt.setDefaults()
+	_, err := dial(context.Background(), "127.0.0.1:8463", 1, opt)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&closed))
+	require.Error(t, probe(context.Background(), "127.0.0.1:8463", opt))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&closed))
+}
+
+func TestProbeIsNotRecorded(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		return nil
+	}))
+	defer srv.Close()
+	var capture bytes.Buffer
+	opt := &Options{
+		Addr:    []string{srv.Addr()},
+		Capture: &capture,
+	}
+	opt.setDefaults()
+	header := capture.Len()
+	require.NoError(t, probe(context.Background(), srv.Addr(), opt))
+	assert.Equal(t, header, capture.Len(), "a probe is not captured")
+}
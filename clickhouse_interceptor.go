@@ -0,0 +1,114 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"fmt"
+)
+
+// The methods of Invocation.Method.
+const (
+	MethodQuery        = "Query"
+	MethodQueryRow     = "QueryRow"
+	MethodStream       = "Stream"
+	MethodExec         = "Exec"
+	MethodPrepareBatch = "PrepareBatch"
+	MethodAsyncInsert  = "AsyncInsert"
+	MethodPing         = "Ping"
+)
+
+// Invocation is a call of the driver seen by the interceptors. Options are the QueryOptions
+// resolved from the context, with a copy of the settings, and the changes to Query, Args and
+// Options are passed on to the next interceptor and the driver.
+type Invocation struct {
+	Method  string
+	Query   string
+	Args    []interface{}
+	Options *QueryOptions
+}
+
+// Invoker runs an invocation. Its result is the driver.Rows of Query, the driver.Row of QueryRow,
+// the driver.Stream of Stream and the driver.Batch of PrepareBatch, and nil for the other methods.
+type Invoker func(ctx context.Context, inv *Invocation) (interface{}, error)
+
+// Interceptor wraps every call of Options.Interceptors. It calls next to proceed, or returns
+// without calling it to short-circuit the call. With database/sql, a short-circuited Query or
+// PrepareBatch can only return an error.
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) (interface{}, error)
+
+// Apply sets options, like the QueryOption of Context.
+func (q *QueryOptions) Apply(options ...QueryOption) error {
+	for _, f := range options {
+		if err := f(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *QueryOptions) QueryID() string {
+	return q.queryID
+}
+
+func (q *QueryOptions) QuotaKey() string {
+	return q.quotaKey
+}
+
+// Settings are the settings sent with the query, they can be changed in place.
+func (q *QueryOptions) Settings() Settings {
+	if q.settings == nil {
+		q.settings = make(Settings)
+	}
+	return q.settings
+}
+
+// intercept runs invoker through the chain of Options.Interceptors.
+func (o *Options) intercept(ctx context.Context, inv *Invocation, invoker Invoker) (interface{}, error) {
+	if len(o.Interceptors) == 0 {
+		return invoker(ctx, inv)
+	}
+	options := queryOptions(ctx)
+	settings := make(Settings, len(options.settings))
+	for k, v := range options.settings {
+		settings[k] = v
+	}
+	options.settings = settings
+	inv.Options = &options
+	next := func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		if inv.Options != nil {
+			ctx = context.WithValue(ctx, _contextOptionKey, *inv.Options)
+		}
+		return invoker(ctx, inv)
+	}
+	for i := len(o.Interceptors) - 1; i >= 0; i-- {
+		interceptor, invoker := o.Interceptors[i], next
+		next = func(ctx context.Context, inv *Invocation) (interface{}, error) {
+			return interceptor(ctx, inv, invoker)
+		}
+	}
+	return next(ctx, inv)
+}
+
+// interceptResult is the error of an interceptor that returned a result of the wrong type.
+func interceptResult(method string, result interface{}) error {
+	return &OpError{
+		Op:  method,
+		Err: fmt.Errorf("unexpected result %T from an interceptor", result),
+	}
+}
@@ -0,0 +1,131 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptorChain(t *testing.T) {
+	var (
+		calls []string
+		opt   = &Options{
+			Interceptors: []Interceptor{
+				func(ctx context.Context, inv *Invocation, next Invoker) (interface{}, error) {
+					calls = append(calls, "first")
+					inv.Query += " SETTINGS max_threads = 1"
+					inv.Options.Settings()["tenant"] = "a"
+					return next(ctx, inv)
+				},
+				func(ctx context.Context, inv *Invocation, next Invoker) (interface{}, error) {
+					calls = append(calls, "second")
+					if err := inv.Options.Apply(WithQueryID("tagged")); err != nil {
+						return nil, err
+					}
+					result, err := next(ctx, inv)
+					calls = append(calls, "second done")
+					return result, err
+				},
+			},
+		}
+		settings = Settings{"max_memory_usage": 1}
+		ctx      = Context(context.Background(), WithSettings(settings))
+	)
+	result, err := opt.intercept(ctx, &Invocation{Method: MethodExec, Query: "SELECT 1"}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		calls = append(calls, "invoker")
+		options := queryOptions(ctx)
+		assert.Equal(t, "SELECT 1 SETTINGS max_threads = 1", inv.Query)
+		assert.Equal(t, "tagged", options.QueryID())
+		assert.Equal(t, "a", options.Settings()["tenant"])
+		assert.Equal(t, 1, options.Settings()["max_memory_usage"])
+		return "result", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "result", result)
+	assert.Equal(t, []string{"first", "second", "invoker", "second done"}, calls)
+	assert.Equal(t, Settings{"max_memory_usage": 1}, settings, "the settings of the caller must not change")
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	var (
+		dials   int
+		open    = errors.New("circuit breaker is open")
+		methods []string
+	)
+	conn, err := Open(&Options{
+		Addr: []string{"127.0.0.1:8463"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			dials++
+			return nil, errors.New("unreachable")
+		},
+		Interceptors: []Interceptor{
+			func(ctx context.Context, inv *Invocation, next Invoker) (interface{}, error) {
+				methods = append(methods, inv.Method)
+				return nil, open
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+	_, err = conn.Query(ctx, "SELECT 1")
+	assert.Equal(t, open, err)
+	assert.Equal(t, open, conn.QueryRow(ctx, "SELECT 1").Err())
+	_, err = conn.(Streamer).Stream(ctx, "SELECT 1")
+	assert.Equal(t, open, err)
+	assert.Equal(t, open, conn.Exec(ctx, "SELECT 1"))
+	_, err = conn.PrepareBatch(ctx, "INSERT INTO t")
+	assert.Equal(t, open, err)
+	assert.Equal(t, open, conn.AsyncInsert(ctx, "INSERT INTO t VALUES (1)", false))
+	assert.Equal(t, open, conn.Ping(ctx))
+	assert.Equal(t, 0, dials)
+	assert.Equal(t, []string{
+		MethodQuery,
+		MethodQueryRow,
+		MethodStream,
+		MethodExec,
+		MethodPrepareBatch,
+		MethodAsyncInsert,
+		MethodPing,
+	}, methods)
+}
+
+func TestInterceptorResult(t *testing.T) {
+	conn, err := Open(&Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Interceptors: []Interceptor{
+			func(ctx context.Context, inv *Invocation, next Invoker) (interface{}, error) {
+				return nil, nil
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Query(context.Background(), "SELECT 1")
+	var opErr *OpError
+	if assert.ErrorAs(t, err, &opErr) {
+		assert.Equal(t, MethodQuery, opErr.Op)
+	}
+	assert.NoError(t, conn.Ping(context.Background()))
+}
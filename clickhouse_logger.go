@@ -0,0 +1,116 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// LogLevel has the values of slog.Level, so the levels convert both ways.
+type LogLevel int
+
+const (
+	LogDebug LogLevel = -4
+	LogInfo  LogLevel = 0
+	LogWarn  LogLevel = 4
+	LogError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l < LogInfo:
+		return "DEBUG"
+	case l < LogWarn:
+		return "INFO"
+	case l < LogError:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// Logger receives the diagnostics of the driver. keyvals are alternating keys and values,
+// like the args of slog.Logger.Log, and the records of a connection always start with
+// conn_id and remote_addr, followed by query_id during a query.
+type Logger interface {
+	Enabled(level LogLevel) bool
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NewLogger returns a Logger that writes one line per record to w, starting at level.
+// It is the logger used by Options.Debug, with os.Stdout and LogDebug.
+func NewLogger(w io.Writer, level LogLevel) Logger {
+	return &textLogger{
+		level:  level,
+		logger: log.New(w, "[proton] ", log.LstdFlags),
+	}
+}
+
+type textLogger struct {
+	level  LogLevel
+	logger *log.Logger
+}
+
+func (l *textLogger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *textLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	var line strings.Builder
+	line.WriteString(level.String())
+	line.WriteByte(' ')
+	line.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		var value interface{} = "!MISSING"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		fmt.Fprintf(&line, " %v=%v", keyvals[i], value)
+	}
+	l.logger.Print(line.String())
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Enabled(LogLevel) bool                { return false }
+func (nopLogger) Log(LogLevel, string, ...interface{}) {}
+
+func (o *Options) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if o.logger != nil && o.logger.Enabled(level) {
+		o.logger.Log(level, msg, keyvals...)
+	}
+}
+
+func (c *connect) log(level LogLevel, msg string, keyvals ...interface{}) {
+	logger := c.opt.logger
+	if logger == nil || !logger.Enabled(level) {
+		return
+	}
+	fields := make([]interface{}, 0, 6+len(keyvals))
+	fields = append(fields, "conn_id", c.id, "remote_addr", c.conn.RemoteAddr().String())
+	if len(c.queryID) != 0 {
+		fields = append(fields, "query_id", c.queryID)
+	}
+	logger.Log(level, msg, append(fields, keyvals...)...)
+}
+
+func (c *connect) debug(msg string, keyvals ...interface{}) {
+	c.log(LogDebug, msg, keyvals...)
+}
@@ -0,0 +1,112 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logRecord struct {
+	level   LogLevel
+	msg     string
+	keyvals []interface{}
+}
+
+type recordLogger struct {
+	level   LogLevel
+	records []logRecord
+}
+
+func (l *recordLogger) Enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l *recordLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	l.records = append(l.records, logRecord{level: level, msg: msg, keyvals: keyvals})
+}
+
+func TestTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, LogInfo)
+	assert.False(t, logger.Enabled(LogDebug))
+	assert.True(t, logger.Enabled(LogWarn))
+	logger.Log(LogWarn, "dial failed", "addr", "127.0.0.1:8463", "odd")
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasPrefix(line, "[proton] "), line)
+	assert.True(t, strings.HasSuffix(line, "WARN dial failed addr=127.0.0.1:8463 odd=!MISSING"), line)
+}
+
+func TestLogLevelString(t *testing.T) {
+	assert.Equal(t, "DEBUG", LogDebug.String())
+	assert.Equal(t, "INFO", LogInfo.String())
+	assert.Equal(t, "WARN", LogWarn.String())
+	assert.Equal(t, "ERROR", LogError.String())
+	assert.Equal(t, "WARN", (LogWarn + 1).String())
+}
+
+func TestOptionsLogger(t *testing.T) {
+	opt := &Options{}
+	opt.setDefaults()
+	assert.Equal(t, nopLogger{}, opt.logger)
+
+	opt = &Options{Debug: true}
+	opt.setDefaults()
+	if assert.IsType(t, &textLogger{}, opt.logger) {
+		assert.True(t, opt.logger.Enabled(LogDebug))
+	}
+
+	logger := &recordLogger{}
+	opt = &Options{Debug: true, Logger: logger}
+	opt.setDefaults()
+	assert.Same(t, logger, opt.logger)
+}
+
+func TestConnLog(t *testing.T) {
+	var (
+		logger       = &recordLogger{level: LogInfo}
+		client, peer = net.Pipe()
+		opt          = &Options{Logger: logger}
+	)
+	defer client.Close()
+	defer peer.Close()
+	opt.setDefaults()
+	c := &connect{
+		id:   42,
+		opt:  opt,
+		conn: client,
+	}
+	c.debug("read data", "rows", 1)
+	assert.Empty(t, logger.records, "debug records are below the level of the logger")
+
+	c.log(LogWarn, "something", "rows", 1)
+	c.queryID = "qid"
+	c.log(LogError, "something else")
+	if assert.Len(t, logger.records, 2) {
+		assert.Equal(t, logRecord{
+			level:   LogWarn,
+			msg:     "something",
+			keyvals: []interface{}{"conn_id", 42, "remote_addr", "pipe", "rows", 1},
+		}, logger.records[0])
+		assert.Equal(t, []interface{}{"conn_id", 42, "remote_addr", "pipe", "query_id", "qid"}, logger.records[1].keyvals)
+	}
+}
@@ -21,16 +21,23 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/compress"
+	protonio "github.com/timeplus-io/proton-go-driver/v2/lib/io"
 )
 
-var CompressionLZ4 compress.Method = compress.LZ4
+var (
+	CompressionLZ4  compress.Method = compress.LZ4
+	CompressionZSTD compress.Method = compress.ZSTD
+)
 
 type Auth struct { // has_control_character
 	Database string
@@ -47,6 +54,8 @@ type ConnOpenStrategy uint8
 const (
 	ConnOpenInOrder ConnOpenStrategy = iota
 	ConnOpenRoundRobin
+	ConnOpenRandom
+	ConnOpenLeastConnections
 )
 
 func ParseDSN(dsn string) (*Options, error) {
@@ -65,11 +74,46 @@ type Options struct {
 	Debug            bool
 	Settings         Settings
 	Compression      *Compression
+	CompressionLevel int           // ZSTD compression level (1-22), 0 means the default level
 	DialTimeout      time.Duration // default 1 second
 	MaxOpenConns     int           // default MaxIdleConns + 5
 	MaxIdleConns     int           // default 5
 	ConnMaxLifetime  time.Duration // default 1 hour
+	ConnMaxIdleTime  time.Duration // 0 means idle connections are not closed because of their idle time
+	MinIdleConns     int           // idle connections kept open in the background, at most MaxIdleConns. native interface only
 	ConnOpenStrategy ConnOpenStrategy
+	// HostSelector overrides ConnOpenStrategy.
+	HostSelector HostSelector
+	// HealthCheckInterval is how often every host is pinged in the background, 0 disables health checks.
+	HealthCheckInterval time.Duration
+	// HostEjectTime is how long a host that failed a dial or a health check is skipped. default 30 seconds
+	HostEjectTime time.Duration
+	// QueryIDGenerator returns the ID of the queries, batches and async inserts the caller did not
+	// set one for with WithQueryID. default a random UUID
+	QueryIDGenerator func(ctx context.Context) string
+	// CancelGracePeriod is how long the server has to end a canceled query before it is killed
+	// with KILL QUERY from another connection. default 2 seconds
+	CancelGracePeriod time.Duration
+	// ServerSideBinding sends the args of a query as query parameters ({name:Type})
+	// instead of rendering them into the SQL text, see bindParameters. The connections
+	// negotiate the protocol revision of query parameters with the server.
+	ServerSideBinding bool
+	// Logger receives the diagnostics of the driver, when it is nil Debug logs to os.Stdout.
+	Logger Logger
+	// Interceptors wrap every Query, QueryRow, Stream, Exec, PrepareBatch, AsyncInsert and Ping call,
+	// of the native and the database/sql interface, the first one is the outermost.
+	Interceptors []Interceptor
+	// OnOperation is called with the measurements of every finished operation, see the prometheus package.
+	OnOperation func(OperationStats)
+	// Capture receives the traffic of every connection, raw and decompressed, with the packet types and
+	// timestamps, to reproduce a session with NewReplayDialer. It is meant for debugging, not production:
+	// the password is masked, but the queries, their arguments and the results are recorded in clear text.
+	Capture io.Writer
+	// Instrumentation observes the dials, handshakes and operations of the driver, see the otel package.
+	Instrumentation Instrumentation
+
+	logger  Logger
+	capture *protonio.CaptureWriter
 }
 
 func (o *Options) fromDSN(in string) error {
@@ -96,10 +140,25 @@ func (o *Options) fromDSN(in string) error {
 		case "debug":
 			o.Debug, _ = strconv.ParseBool(params.Get(v))
 		case "compress":
-			if on, _ := strconv.ParseBool(params.Get(v)); on {
+			switch p := strings.ToLower(params.Get(v)); p {
+			case "lz4":
 				o.Compression = &Compression{
 					Method: CompressionLZ4,
 				}
+			case "zstd":
+				o.Compression = &Compression{
+					Method: CompressionZSTD,
+				}
+			default:
+				if on, _ := strconv.ParseBool(p); on {
+					o.Compression = &Compression{
+						Method: CompressionLZ4,
+					}
+				}
+			}
+		case "compress_level":
+			if o.CompressionLevel, err = strconv.Atoi(params.Get(v)); err != nil {
+				return fmt.Errorf("proton [dsn parse]: compress level: %s", err)
 			}
 		case "dial_timeout":
 			duration, err := time.ParseDuration(params.Get(v))
@@ -107,6 +166,8 @@ func (o *Options) fromDSN(in string) error {
 				return fmt.Errorf("proton [dsn parse]: dial timeout: %s", err)
 			}
 			o.DialTimeout = duration
+		case "server_side_binding":
+			o.ServerSideBinding, _ = strconv.ParseBool(params.Get(v))
 		case "secure":
 			secure = true
 		case "skip_verify":
@@ -117,7 +178,29 @@ func (o *Options) fromDSN(in string) error {
 				o.ConnOpenStrategy = ConnOpenInOrder
 			case "round_robin":
 				o.ConnOpenStrategy = ConnOpenRoundRobin
+			case "random":
+				o.ConnOpenStrategy = ConnOpenRandom
+			case "least_connections":
+				o.ConnOpenStrategy = ConnOpenLeastConnections
+			}
+		case "health_check_interval":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("proton [dsn parse]: health check interval: %s", err)
+			}
+			o.HealthCheckInterval = duration
+		case "host_eject_time":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("proton [dsn parse]: host eject time: %s", err)
+			}
+			o.HostEjectTime = duration
+		case "cancel_grace_period":
+			duration, err := time.ParseDuration(params.Get(v))
+			if err != nil {
+				return fmt.Errorf("proton [dsn parse]: cancel grace period: %s", err)
 			}
+			o.CancelGracePeriod = duration
 		default:
 			switch p := strings.ToLower(params.Get(v)); p {
 			case "true":
@@ -159,4 +242,29 @@ func (o *Options) setDefaults() {
 	if o.ConnMaxLifetime == 0 {
 		o.ConnMaxLifetime = time.Hour
 	}
+	if o.MinIdleConns > o.MaxIdleConns {
+		o.MinIdleConns = o.MaxIdleConns
+	}
+	switch {
+	case o.Logger != nil:
+		o.logger = o.Logger
+	case o.Debug:
+		o.logger = NewLogger(os.Stdout, LogDebug)
+	default:
+		o.logger = nopLogger{}
+	}
+	if o.Capture != nil && o.capture == nil {
+		o.capture = protonio.NewCaptureWriter(o.Capture)
+	}
+	if o.HostEjectTime == 0 {
+		o.HostEjectTime = 30 * time.Second
+	}
+	if o.QueryIDGenerator == nil {
+		o.QueryIDGenerator = func(context.Context) string {
+			return uuid.NewString()
+		}
+	}
+	if o.CancelGracePeriod == 0 {
+		o.CancelGracePeriod = 2 * time.Second
+	}
 }
@@ -0,0 +1,67 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDSNCompression(t *testing.T) {
+	for dsn, expected := range map[string]struct {
+		compression *Compression
+		level       int
+	}{
+		"proton://127.0.0.1:8463":                                {nil, 0},
+		"proton://127.0.0.1:8463?compress=false":                 {nil, 0},
+		"proton://127.0.0.1:8463?compress=true":                  {&Compression{Method: CompressionLZ4}, 0},
+		"proton://127.0.0.1:8463?compress=lz4":                   {&Compression{Method: CompressionLZ4}, 0},
+		"proton://127.0.0.1:8463?compress=zstd":                  {&Compression{Method: CompressionZSTD}, 0},
+		"proton://127.0.0.1:8463?compress=ZSTD&compress_level=9": {&Compression{Method: CompressionZSTD}, 9},
+		"proton://127.0.0.1:8463?compress_level=9&compress=zstd": {&Compression{Method: CompressionZSTD}, 9},
+		"proton://127.0.0.1:8463?compress_level=9":               {nil, 9},
+	} {
+		opt, err := ParseDSN(dsn)
+		if assert.NoError(t, err, dsn) {
+			assert.Equal(t, expected.compression, opt.Compression, dsn)
+			assert.Equal(t, expected.level, opt.CompressionLevel, dsn)
+		}
+	}
+	_, err := ParseDSN("proton://127.0.0.1:8463?compress=zstd&compress_level=high")
+	assert.Error(t, err)
+}
+
+func TestParseDSNHosts(t *testing.T) {
+	opt, err := ParseDSN("proton://127.0.0.1:8463,127.0.0.2:8463?connection_open_strategy=least_connections&health_check_interval=5s&host_eject_time=1m")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"127.0.0.1:8463", "127.0.0.2:8463"}, opt.Addr)
+		assert.Equal(t, ConnOpenLeastConnections, opt.ConnOpenStrategy)
+		assert.Equal(t, 5*time.Second, opt.HealthCheckInterval)
+		assert.Equal(t, time.Minute, opt.HostEjectTime)
+	}
+	opt, err = ParseDSN("proton://127.0.0.1:8463?connection_open_strategy=random")
+	if assert.NoError(t, err) {
+		assert.Equal(t, ConnOpenRandom, opt.ConnOpenStrategy)
+		assert.Equal(t, time.Duration(0), opt.HealthCheckInterval)
+		assert.Equal(t, 30*time.Second, opt.HostEjectTime)
+	}
+	_, err = ParseDSN("proton://127.0.0.1:8463?health_check_interval=often")
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
+)
+
+func newTestPool(opt *Options) *proton {
+	opt.setDefaults()
+	return &proton{
+		opt:   opt,
+		idle:  make(chan *connect, opt.MaxIdleConns),
+		open:  make(chan struct{}, opt.MaxOpenConns),
+		hosts: newHosts(opt),
+		done:  make(chan struct{}),
+	}
+}
+
+func newTestConn(connectedAt, lastUsedIn time.Time) *connect {
+	conn, _ := net.Pipe()
+	return &connect{
+		conn:        conn,
+		stream:      io.NewStream(conn),
+		connectedAt: connectedAt,
+		lastUsedIn:  lastUsedIn,
+	}
+}
+
+func TestPoolReap(t *testing.T) {
+	var (
+		now = time.Now()
+		ch  = newTestPool(&Options{
+			ConnMaxIdleTime: time.Minute,
+			ConnMaxLifetime: time.Hour,
+		})
+		fresh = newTestConn(now, now)
+	)
+	ch.idle <- newTestConn(now, now.Add(-2*time.Minute))
+	ch.idle <- fresh
+	ch.idle <- newTestConn(now.Add(-2*time.Hour), now)
+	ch.reap()
+	if assert.Len(t, ch.idle, 1) {
+		assert.Same(t, fresh, <-ch.idle)
+	}
+	stats := ch.Stats()
+	assert.Equal(t, int64(1), stats.MaxIdleTimeClosed)
+	assert.Equal(t, int64(1), stats.MaxLifetimeClosed)
+}
+
+func TestPoolRelease(t *testing.T) {
+	var (
+		now = time.Now()
+		ch  = newTestPool(&Options{
+			MaxIdleConns:    1,
+			ConnMaxLifetime: time.Hour,
+		})
+		first = newTestConn(now, now.Add(-time.Hour))
+	)
+	ch.release(first, nil)
+	assert.True(t, first.lastUsedIn.After(now.Add(-time.Second)), "release must reset the idle time")
+	ch.release(newTestConn(now, now), nil)
+	ch.release(newTestConn(now.Add(-2*time.Hour), now), nil)
+	broken := newTestConn(now, now)
+	ch.release(broken, errors.New("broken"))
+	assert.True(t, broken.closed)
+	if assert.Len(t, ch.idle, 1) {
+		assert.Same(t, first, <-ch.idle)
+	}
+	stats := ch.Stats()
+	assert.Equal(t, int64(1), stats.MaxIdleClosed)
+	assert.Equal(t, int64(1), stats.MaxLifetimeClosed)
+}
+
+func TestPoolWaitStats(t *testing.T) {
+	var (
+		now = time.Now()
+		ch  = newTestPool(&Options{
+			MaxOpenConns: 1,
+			DialTimeout:  time.Second,
+		})
+		idle = newTestConn(now, now)
+	)
+	ch.idle <- idle
+	ch.open <- struct{}{}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-ch.open
+	}()
+	conn, err := ch.acquire(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, idle, conn)
+	stats := ch.Stats()
+	assert.Equal(t, int64(1), stats.WaitCount)
+	assert.GreaterOrEqual(t, stats.WaitDuration, 20*time.Millisecond)
+
+	ch.opt.DialTimeout = 10 * time.Millisecond
+	_, err = ch.acquire(context.Background())
+	assert.Equal(t, ErrAcquireConnTimeout, err)
+	assert.Equal(t, int64(2), ch.Stats().WaitCount)
+	assert.Equal(t, int64(1), ch.Stats().AcquireTimeouts)
+}
+
+func TestPoolWarmUp(t *testing.T) {
+	dials := make(chan struct{}, 10)
+	conn, err := Open(&Options{
+		Addr: []string{"127.0.0.1:8463"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			dials <- struct{}{}
+			return nil, errors.New("unreachable")
+		},
+		MaxIdleConns: 2,
+		MinIdleConns: 5,
+	})
+	require.NoError(t, err)
+	select {
+	case <-dials:
+	case <-time.After(time.Second):
+		t.Fatal("the pool was not warmed up")
+	}
+	require.NoError(t, conn.Close())
+	stats := conn.Stats()
+	assert.GreaterOrEqual(t, stats.Dials, int64(1))
+	assert.Equal(t, stats.Dials, stats.DialErrors)
+	assert.Equal(t, 2, conn.(*proton).opt.MinIdleConns)
+}
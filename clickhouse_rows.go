@@ -21,9 +21,15 @@ import (
 	"database/sql"
 	"io"
 
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
 )
 
+type (
+	BlockReader     = driver.BlockReader
+	QueryIdentifier = driver.QueryIdentifier
+)
+
 type rows struct {
 	err       error
 	row       int
@@ -32,9 +38,14 @@ type rows struct {
 	errors    chan error
 	stream    chan *proto.Block
 	columns   []string
+	queryID   string
 	structMap structMap
 }
 
+func (r *rows) QueryID() string {
+	return r.queryID
+}
+
 func (r *rows) Next() (result bool) {
 	defer func() {
 		if !result {
@@ -68,6 +79,42 @@ next:
 	return r.row <= r.block.Rows()
 }
 
+func (r *rows) NextBlock() (*proto.Block, error) {
+	if r.block == nil {
+		r.Close()
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, io.EOF
+	}
+	if r.row == 0 && r.block.Rows() != 0 {
+		r.row = r.block.Rows()
+		return r.block, nil
+	}
+	for {
+		select {
+		case err := <-r.errors:
+			if err != nil {
+				r.err = err
+				r.Close()
+				return nil, err
+			}
+		case block := <-r.stream:
+			switch {
+			case block == nil:
+				r.Close()
+				return nil, io.EOF
+			case block.Packet == proto.ServerTotals:
+				r.row, r.block, r.totals = 0, nil, block
+				r.Close()
+				return nil, io.EOF
+			}
+			r.row, r.block = block.Rows(), block
+			return block, nil
+		}
+	}
+}
+
 func (r *rows) Scan(dest ...interface{}) error {
 	if r.block == nil || (r.row == 0 && r.row >= r.block.Rows()) { // call without next when result is empty
 		return io.EOF
@@ -160,3 +207,9 @@ func (r *row) Scan(dest ...interface{}) error {
 	}
 	return r.rows.Close()
 }
+
+var (
+	_ (driver.Rows)            = (*rows)(nil)
+	_ (driver.BlockReader)     = (*rows)(nil)
+	_ (driver.QueryIdentifier) = (*rows)(nil)
+)
@@ -22,6 +22,7 @@ import (
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
 )
 
 type columnType struct {
@@ -48,11 +49,18 @@ func (c *columnType) DatabaseTypeName() string {
 }
 
 func (r *rows) ColumnTypes() []driver.ColumnType {
-	types := make([]driver.ColumnType, 0, len(r.columns))
-	for i, c := range r.block.Columns {
+	return columnTypes(r.block)
+}
+
+func columnTypes(block *proto.Block) []driver.ColumnType {
+	var (
+		names = block.ColumnsNames()
+		types = make([]driver.ColumnType, 0, len(block.Columns))
+	)
+	for i, c := range block.Columns {
 		_, nullable := c.(*column.Nullable)
 		types = append(types, &columnType{
-			name:     r.columns[i],
+			name:     names[i],
 			chType:   string(c.Type()),
 			nullable: nullable,
 			scanType: c.ScanType(),
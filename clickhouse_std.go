@@ -34,8 +34,9 @@ import (
 var globalConnID int64
 
 type stdConnOpener struct {
-	err error
-	opt *Options
+	err   error
+	opt   *Options
+	hosts *hosts
 }
 
 func (o *stdConnOpener) Driver() driver.Driver {
@@ -46,21 +47,33 @@ func (o *stdConnOpener) Connect(ctx context.Context) (_ driver.Conn, err error)
 	if o.err != nil {
 		return nil, o.err
 	}
-	var (
-		conn   *connect
-		connID = int(atomic.AddInt64(&globalConnID, 1))
-	)
-	for num := range o.opt.Addr {
-		if o.opt.ConnOpenStrategy == ConnOpenRoundRobin {
-			num = int(connID) % len(o.opt.Addr)
-		}
-		if conn, err = dial(ctx, o.opt.Addr[num], connID, o.opt); err == nil {
-			return &stdDriver{
-				conn: conn,
-			}, nil
-		}
+	conn, err := o.hosts.dial(ctx, int(atomic.AddInt64(&globalConnID, 1)))
+	if err != nil {
+		return nil, err
+	}
+	conn.kill = o.killQuery
+	return &stdDriver{
+		conn: conn,
+	}, nil
+}
+
+// killQuery kills a query that ignored its cancel from a new connection, database/sql
+// does not give access to its pool.
+func (o *stdConnOpener) killQuery(ctx context.Context, queryID string) error {
+	conn, err := o.hosts.dial(ctx, int(atomic.AddInt64(&globalConnID, 1)))
+	if err != nil {
+		return err
 	}
-	return nil, err
+	defer conn.close()
+	return conn.killQuery(ctx, queryID)
+}
+
+// Close stops the health checks, sql.DB calls it on Close.
+func (o *stdConnOpener) Close() error {
+	if o.hosts != nil {
+		o.hosts.close()
+	}
+	return nil
 }
 
 func init() {
@@ -78,6 +91,9 @@ func OpenDB(opt *Options) *sql.DB {
 	if opt.ConnMaxLifetime > 0 {
 		settings = append(settings, "SetConnMaxLifetime")
 	}
+	if opt.ConnMaxIdleTime > 0 {
+		settings = append(settings, "SetConnMaxIdleTime")
+	}
 	if len(settings) != 0 {
 		return sql.OpenDB(&stdConnOpener{
 			err: fmt.Errorf("cannot connect. invalid settings. use %s (see https://pkg.go.dev/database/sql)", strings.Join(settings, ",")),
@@ -85,7 +101,8 @@ func OpenDB(opt *Options) *sql.DB {
 	}
 	opt.setDefaults()
 	return sql.OpenDB(&stdConnOpener{
-		opt: opt,
+		opt:   opt,
+		hosts: newHosts(opt),
 	})
 }
 
@@ -99,7 +116,20 @@ func (d *stdDriver) Open(dsn string) (_ driver.Conn, err error) {
 	if err := opt.fromDSN(dsn); err != nil {
 		return nil, err
 	}
-	return (&stdConnOpener{opt: &opt}).Connect(context.Background())
+	// without a shared opener there is nothing to stop the health checks
+	opt.HealthCheckInterval = 0
+	return (&stdConnOpener{opt: &opt, hosts: newHosts(&opt)}).Connect(context.Background())
+}
+
+func (d *stdDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	var opt Options
+	if err := opt.fromDSN(dsn); err != nil {
+		return nil, err
+	}
+	return &stdConnOpener{
+		opt:   &opt,
+		hosts: newHosts(&opt),
+	}, nil
 }
 
 func (std *stdDriver) ResetSession(ctx context.Context) error {
@@ -109,7 +139,12 @@ func (std *stdDriver) ResetSession(ctx context.Context) error {
 	return nil
 }
 
-func (std *stdDriver) Ping(ctx context.Context) error { return std.conn.ping(ctx) }
+func (std *stdDriver) Ping(ctx context.Context) error {
+	_, err := std.conn.opt.intercept(ctx, &Invocation{Method: MethodPing}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		return nil, std.conn.ping(ctx)
+	})
+	return err
+}
 
 func (std *stdDriver) Begin() (driver.Tx, error) { return std, nil }
 
@@ -136,19 +171,48 @@ func (std *stdDriver) ExecContext(ctx context.Context, query string, args []driv
 		if len(args) != 0 {
 			return nil, errors.New("proton: you can't use parameters in an asynchronous insert")
 		}
-		return driver.RowsAffected(0), std.conn.asyncInsert(ctx, query, options.async.wait)
+		_, err := std.conn.opt.intercept(ctx, &Invocation{Method: MethodAsyncInsert, Query: query}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+			return nil, std.conn.asyncInsert(ctx, inv.Query, options.async.wait)
+		})
+		return driver.RowsAffected(0), err
 	}
-	if err := std.conn.exec(ctx, query, rebind(args)...); err != nil {
+	result, err := std.conn.opt.intercept(ctx, &Invocation{Method: MethodExec, Query: query, Args: rebind(args)}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		result, err := std.conn.execWithResult(ctx, inv.Query, inv.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	switch result := result.(type) {
+	case *ExecResult:
+		if result != nil {
+			return driver.RowsAffected(result.WrittenRows), nil
+		}
+	case nil:
+	default:
+		return nil, interceptResult(MethodExec, result)
+	}
 	return driver.RowsAffected(0), nil
 }
 
 func (std *stdDriver) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	r, err := std.conn.query(ctx, func(*connect, error) {}, query, rebind(args)...)
+	result, err := std.conn.opt.intercept(ctx, &Invocation{Method: MethodQuery, Query: query, Args: rebind(args)}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		r, err := std.conn.query(ctx, func(*connect, error) {}, inv.Query, inv.Args...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	r, ok := result.(*rows)
+	if !ok || r == nil {
+		return nil, interceptResult(MethodQuery, result)
+	}
 	return &stdRows{
 		rows: r,
 	}, nil
@@ -159,13 +223,23 @@ func (std *stdDriver) Prepare(query string) (driver.Stmt, error) {
 }
 
 func (std *stdDriver) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	batch, err := std.conn.prepareBatch(ctx, query, func(*connect, error) {})
+	result, err := std.conn.opt.intercept(ctx, &Invocation{Method: MethodPrepareBatch, Query: query}, func(ctx context.Context, inv *Invocation) (interface{}, error) {
+		batch, err := std.conn.prepareBatch(ctx, inv.Query, func(*connect, error) {})
+		if err != nil {
+			return nil, err
+		}
+		return batch, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	std.commit = batch.Send
+	b, ok := result.(*batch)
+	if !ok || b == nil {
+		return nil, interceptResult(MethodPrepareBatch, result)
+	}
+	std.commit = b.Send
 	return &stdBatch{
-		batch: batch,
+		batch: b,
 	}, nil
 }
 
@@ -184,6 +258,7 @@ func (s *stdBatch) Exec(args []driver.Value) (driver.Result, error) {
 	if err := s.batch.Append(values...); err != nil {
 		return nil, err
 	}
+	// the row is only written by Commit
 	return driver.RowsAffected(0), nil
 }
 
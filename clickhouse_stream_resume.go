@@ -0,0 +1,446 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// ResumePolicy configures the resumable mode of Conn.Stream (see WithStreamResume).
+//
+// Cursor is the name of a column of the result set that grows monotonically,
+// e.g. _tp_time or _tp_sn. When the connection is lost the query is re-issued from
+// the last seen cursor: seek_to is set to its time, to its sequence number for _tp_sn
+// (which is per shard, so only for single shard streams) or to earliest otherwise,
+// and the query is wrapped with a cursor >= last predicate. The rows of the last cursor
+// value that were already delivered are dropped by the driver, so rows that share it are
+// neither lost nor repeated.
+type ResumePolicy struct {
+	Cursor     string
+	MaxRetries int           // consecutive failed attempts, 0 means retry forever
+	Backoff    time.Duration // default 100ms, doubled after every failed attempt
+	MaxBackoff time.Duration // default 10 seconds
+}
+
+func (p *ResumePolicy) setDefaults() {
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 10 * time.Second
+	}
+}
+
+func (p *ResumePolicy) backoff(attempt int) time.Duration {
+	return exponentialBackoff(p.Backoff, p.MaxBackoff, attempt)
+}
+
+func exponentialBackoff(backoff, max time.Duration, attempt int) time.Duration {
+	for i := 1; i < attempt && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+func (ch *proton) resumableStream(ctx context.Context, policy ResumePolicy, query string, args ...interface{}) (driver.Stream, error) {
+	policy.setDefaults()
+	s := &resumableSubscription{
+		ch:     ch,
+		ctx:    ctx,
+		query:  query,
+		args:   args,
+		policy: policy,
+		blocks: make(chan *proto.Block, 2),
+		done:   make(chan struct{}),
+		structMap: structMap{
+			cache: make(map[reflect.Type]map[string][]int),
+		},
+	}
+	current, err := s.subscribe()
+	if err != nil {
+		return nil, err
+	}
+	s.header = current.header
+	for i, name := range s.header.ColumnsNames() {
+		if name == policy.Cursor {
+			s.cursor.index = i
+			s.cursor.found = true
+		}
+	}
+	if !s.cursor.found {
+		current.Close()
+		return nil, &OpError{
+			Op:  "Stream",
+			Err: fmt.Errorf("resume cursor column %q is not in the result set", policy.Cursor),
+		}
+	}
+	go s.run(current)
+	return s, nil
+}
+
+type resumableSubscription struct {
+	ch     *proton
+	ctx    context.Context
+	query  string
+	args   []interface{}
+	policy ResumePolicy
+	cursor struct {
+		index int
+		found bool
+		value interface{}
+		// seen counts the delivered rows of value by fingerprint, skip is what is left
+		// of it to drop from the resumed query.
+		seen map[string]int
+		skip map[string]int
+	}
+	err       error
+	mutex     sync.Mutex
+	header    *proto.Block
+	current   *subscription
+	queryID   string
+	progress  proto.Progress
+	blocks    chan *proto.Block
+	rows      chan driver.StreamRow
+	rowsOnce  sync.Once
+	done      chan struct{}
+	doneOnce  sync.Once
+	structMap structMap
+}
+
+func (s *resumableSubscription) subscribe() (*subscription, error) {
+	var (
+		ctx     = s.ctx
+		query   = s.query
+		options = queryOptions(ctx)
+	)
+	if s.cursor.value != nil {
+		settings := make(Settings, len(options.settings)+1)
+		for k, v := range options.settings {
+			settings[k] = v
+		}
+		settings["seek_to"] = seekTo(s.policy.Cursor, s.cursor.value)
+		options.settings = settings
+		ctx = context.WithValue(ctx, _contextOptionKey, options)
+		query = fmt.Sprintf("SELECT * FROM (%s) WHERE %s >= %s",
+			query,
+			lexer.QuoteIdentifier(s.policy.Cursor),
+			cursorLiteral(s.cursor.value),
+		)
+		s.cursor.skip = make(map[string]int, len(s.cursor.seen))
+		for k, n := range s.cursor.seen {
+			s.cursor.skip[k] = n
+		}
+	}
+	conn, err := s.ch.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.subscribe(ctx, s.ch.release, query, s.args...)
+}
+
+func (s *resumableSubscription) run(current *subscription) {
+	defer close(s.blocks)
+	for {
+		s.mutex.Lock()
+		s.current = current
+		s.queryID = current.queryID
+		s.mutex.Unlock()
+		err := s.forward(current)
+		s.mutex.Lock()
+		{
+			progress := current.Progress()
+			s.progress.Rows += progress.Rows
+			s.progress.Bytes += progress.Bytes
+			s.progress.TotalRows += progress.TotalRows
+			s.progress.WroteRows += progress.WroteRows
+			s.progress.WroteBytes += progress.WroteBytes
+			s.current = nil
+		}
+		s.mutex.Unlock()
+		switch {
+		case s.closed():
+			return
+		case !isConnError(err):
+			s.setErr(err)
+			return
+		}
+		s.ch.opt.log(LogWarn, "stream interrupted, resuming", "error", err)
+		if current, err = s.resume(); err != nil || current == nil {
+			s.setErr(err)
+			return
+		}
+	}
+}
+
+// forward passes the blocks of the current subscription through, remembering the cursor.
+func (s *resumableSubscription) forward(current *subscription) error {
+	for {
+		select {
+		case block, ok := <-current.Blocks():
+			if !ok {
+				return current.Err()
+			}
+			block, err := s.dedup(block)
+			if err != nil {
+				current.Close()
+				return err
+			}
+			s.remember(block)
+			select {
+			case s.blocks <- block:
+			case <-s.done:
+				current.Close()
+				return nil
+			}
+		case <-s.done:
+			current.Close()
+			return nil
+		}
+	}
+}
+
+// remember keeps the last cursor value of a block and the rows delivered with it.
+func (s *resumableSubscription) remember(block *proto.Block) {
+	rows := block.Rows()
+	if rows == 0 {
+		return
+	}
+	cursor := block.Columns[s.cursor.index]
+	if last := cursor.Row(rows-1, false); s.cursor.seen == nil || !cursorEqual(last, s.cursor.value) {
+		s.cursor.value, s.cursor.seen = last, make(map[string]int)
+	}
+	for i := rows - 1; i >= 0 && cursorEqual(cursor.Row(i, false), s.cursor.value); i-- {
+		s.cursor.seen[fingerprint(block, i)]++
+	}
+}
+
+// dedup drops the rows of a resumed query that were delivered before the connection was lost.
+func (s *resumableSubscription) dedup(block *proto.Block) (*proto.Block, error) {
+	if len(s.cursor.skip) == 0 || block.Rows() == 0 {
+		return block, nil
+	}
+	var (
+		cursor = block.Columns[s.cursor.index]
+		keep   = make([]int, 0, block.Rows())
+	)
+	for i := 0; i < block.Rows(); i++ {
+		if s.cursor.skip != nil && cursorEqual(cursor.Row(i, false), s.cursor.value) {
+			if key := fingerprint(block, i); s.cursor.skip[key] > 0 {
+				s.cursor.skip[key]--
+				continue
+			}
+		} else {
+			s.cursor.skip = nil // past the last value, nothing more to drop
+		}
+		keep = append(keep, i)
+	}
+	return filterBlock(block, keep)
+}
+
+// filterBlock returns the rows keep of block, or block when it keeps all of them.
+func filterBlock(block *proto.Block, keep []int) (*proto.Block, error) {
+	if len(keep) == block.Rows() {
+		return block, nil
+	}
+	filtered := &proto.Block{Packet: block.Packet}
+	for i, c := range block.Columns {
+		if err := filtered.AddColumn(block.ColumnsNames()[i], c.Type()); err != nil {
+			return nil, err
+		}
+	}
+	values := make([]interface{}, len(block.Columns))
+	for _, row := range keep {
+		for i, c := range block.Columns {
+			values[i] = c.Row(row, false)
+		}
+		if err := filtered.Append(values...); err != nil {
+			return nil, err
+		}
+	}
+	return filtered, nil
+}
+
+func (s *resumableSubscription) resume() (*subscription, error) {
+	var lastErr error
+	for attempt := 1; s.policy.MaxRetries == 0 || attempt <= s.policy.MaxRetries; attempt++ {
+		timer := time.NewTimer(s.policy.backoff(attempt))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return nil, s.ctx.Err()
+		case <-s.done:
+			timer.Stop()
+			return nil, nil
+		case <-timer.C:
+		}
+		current, err := s.subscribe()
+		if err == nil {
+			return current, nil
+		}
+		if !isConnError(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, &OpError{
+		Op:  "Stream",
+		Err: fmt.Errorf("could not resume after %d attempts: %w", s.policy.MaxRetries, lastErr),
+	}
+}
+
+func (s *resumableSubscription) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *resumableSubscription) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func (s *resumableSubscription) Columns() []string {
+	return s.header.ColumnsNames()
+}
+
+func (s *resumableSubscription) ColumnTypes() []driver.ColumnType {
+	return columnTypes(s.header)
+}
+
+func (s *resumableSubscription) Blocks() <-chan *proto.Block {
+	return s.blocks
+}
+
+func (s *resumableSubscription) Rows() <-chan driver.StreamRow {
+	s.rowsOnce.Do(func() {
+		s.rows = streamRows(s.blocks, s.done, s.structMap)
+	})
+	return s.rows
+}
+
+func (s *resumableSubscription) Progress() proto.Progress {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	progress := s.progress
+	if s.current != nil {
+		current := s.current.Progress()
+		progress.Rows += current.Rows
+		progress.Bytes += current.Bytes
+		progress.TotalRows += current.TotalRows
+		progress.WroteRows += current.WroteRows
+		progress.WroteBytes += current.WroteBytes
+	}
+	return progress
+}
+
+// QueryID is the ID of the current query, a resumed stream runs a new query.
+func (s *resumableSubscription) QueryID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.queryID
+}
+
+func (s *resumableSubscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+func (s *resumableSubscription) Close() error {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+	for range s.blocks {
+	}
+	return s.Err()
+}
+
+// isConnError reports whether err means the connection was lost, as opposed to
+// an error returned by the server or a cancelled context.
+func isConnError(err error) bool {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// seekTo is the seek_to setting that resumes a query at a cursor value, it may be before it.
+func seekTo(cursor string, v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05.000Z")
+	case int64:
+		if cursor == "_tp_sn" {
+			return strconv.FormatInt(v, 10)
+		}
+	}
+	return "earliest"
+}
+
+func cursorEqual(a, b interface{}) bool {
+	if a, ok := a.(time.Time); ok {
+		b, ok := b.(time.Time)
+		return ok && a.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// fingerprint identifies a row by its values.
+func fingerprint(block *proto.Block, row int) string {
+	var key strings.Builder
+	for _, c := range block.Columns {
+		fmt.Fprintf(&key, "%v\x00", c.Row(row, false))
+	}
+	return key.String()
+}
+
+func cursorLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return fmt.Sprintf("to_datetime64('%s', 9, 'UTC')", v.UTC().Format("2006-01-02 15:04:05.999999999"))
+	}
+	return format(time.UTC, v)
+}
+
+var _ (driver.Stream) = (*resumableSubscription)(nil)
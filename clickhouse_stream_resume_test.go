@@ -0,0 +1,125 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestResumePolicyBackoff(t *testing.T) {
+	policy := ResumePolicy{
+		Backoff:    100 * time.Millisecond,
+		MaxBackoff: time.Second,
+	}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.backoff(4))
+	assert.Equal(t, time.Second, policy.backoff(5))
+	assert.Equal(t, time.Second, policy.backoff(100))
+}
+
+func TestIsConnError(t *testing.T) {
+	assert.True(t, isConnError(io.EOF))
+	assert.True(t, isConnError(&net.OpError{Op: "read", Err: io.ErrUnexpectedEOF}))
+	assert.False(t, isConnError(nil))
+	assert.False(t, isConnError(context.Canceled))
+	assert.False(t, isConnError(&Exception{Code: 62}))
+}
+
+func TestCursorLiteral(t *testing.T) {
+	assert.Equal(t, "42", cursorLiteral(int64(42)))
+	assert.Equal(t,
+		"to_datetime64('2022-01-12 06:00:00.123', 9, 'UTC')",
+		cursorLiteral(time.Date(2022, 1, 12, 6, 0, 0, 123000000, time.UTC)),
+	)
+}
+
+func TestSeekTo(t *testing.T) {
+	assert.Equal(t, "2022-01-12T06:00:00.123Z", seekTo("_tp_time", time.Date(2022, 1, 12, 6, 0, 0, 123456789, time.UTC)))
+	assert.Equal(t, "42", seekTo("_tp_sn", int64(42)))
+	assert.Equal(t, "earliest", seekTo("id", int64(42)))
+	assert.Equal(t, "earliest", seekTo("name", "a"))
+}
+
+func TestResumeTiedCursor(t *testing.T) {
+	var received []*protontest.Query
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		received = append(received, q)
+		return w.Data(resumeBlock(t))
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var (
+		t1 = time.Date(2022, 1, 12, 6, 0, 0, 0, time.UTC)
+		t2 = t1.Add(time.Microsecond)
+		t3 = t2.Add(time.Second)
+		s  = &resumableSubscription{
+			ch:     conn.(*proton),
+			ctx:    context.Background(),
+			query:  "SELECT * FROM events",
+			policy: ResumePolicy{Cursor: "_tp_time"},
+		}
+	)
+	s.remember(resumeBlock(t, t1, "a", t2, "b", t2, "c"))
+	current, err := s.subscribe()
+	require.NoError(t, err)
+	current.Close()
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "SELECT * FROM (SELECT * FROM events) WHERE `_tp_time` >= to_datetime64('2022-01-12 06:00:00.000001', 9, 'UTC')", received[0].Body)
+		assert.Equal(t, "2022-01-12T06:00:00.000Z", received[0].Settings["seek_to"])
+	}
+
+	// the resumed query reads b and c again, d was not delivered and shares their time
+	block, err := s.dedup(resumeBlock(t, t2, "b", t2, "d", t2, "c", t3, "e"))
+	require.NoError(t, err)
+	s.remember(block)
+	assert.Equal(t, []string{"d", "e"}, resumeIDs(block))
+	block, err = s.dedup(resumeBlock(t, t3, "f"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f"}, resumeIDs(block))
+}
+
+func resumeBlock(t *testing.T, rows ...interface{}) *proto.Block {
+	var block proto.Block
+	require.NoError(t, block.AddColumn("_tp_time", "datetime64(9, 'UTC')"))
+	require.NoError(t, block.AddColumn("id", "string"))
+	for i := 0; i < len(rows); i += 2 {
+		require.NoError(t, block.Append(rows[i], rows[i+1]))
+	}
+	return &block
+}
+
+func resumeIDs(block *proto.Block) []string {
+	var ids []string
+	for i := 0; i < block.Rows(); i++ {
+		ids = append(ids, block.Columns[1].Row(i, false).(string))
+	}
+	return ids
+}
@@ -0,0 +1,443 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+type (
+	TailOptions = driver.TailOptions
+	Tailer      = driver.Tailer
+)
+
+// Tail runs two queries. The historical phase reads table(stream) ordered by the cursor. The live
+// phase is a streaming query that seeks to since and filters out the rows up to the last cursor
+// of the history, so the rows written while the history was read are neither lost nor repeated.
+// The sequence numbers of _tp_sn are per shard, they are stitched shard by shard with _tp_shard.
+// Another cursor is stitched from its last value inclusively, the rows of the history with that
+// value are dropped from the live phase.
+func (ch *proton) Tail(ctx context.Context, stream string, since time.Time, opts TailOptions) (driver.Rows, error) {
+	if opts.Cursor == "" {
+		opts.Cursor = "_tp_sn"
+	}
+	t := &tailRows{
+		ch:     ch,
+		ctx:    ctx,
+		stream: stream,
+		since:  since,
+		opts:   opts,
+		structMap: structMap{
+			cache: make(map[reflect.Type]map[string][]int),
+		},
+	}
+	current, err := ch.Query(ctx, t.query(), opts.Args...)
+	if err != nil {
+		return nil, err
+	}
+	t.current, t.index, t.shard = current, -1, -1
+	for i, name := range current.Columns() {
+		switch {
+		case name == opts.Cursor:
+			t.index = i
+		case name == "_tp_shard" && t.sharded():
+			t.shard = i
+		}
+	}
+	switch {
+	case t.index == -1:
+		current.Close()
+		return nil, &OpError{
+			Op:  "Tail",
+			Err: fmt.Errorf("cursor column %q is not in the result set", opts.Cursor),
+		}
+	case t.shard == -1 && t.sharded():
+		current.Close()
+		return nil, &OpError{
+			Op:  "Tail",
+			Err: errors.New("column _tp_shard is not in the result set"),
+		}
+	}
+	t.header = &proto.Block{}
+	visible := len(current.Columns()) - len(t.extra())
+	if visible < 0 {
+		visible = 0
+	}
+	for i, c := range current.ColumnTypes()[:visible] {
+		if err := t.header.AddColumn(current.Columns()[i], column.Type(c.DatabaseTypeName())); err != nil {
+			current.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+type tailRows struct {
+	ch     *proton
+	ctx    context.Context
+	stream string
+	since  time.Time
+	opts   TailOptions
+	err    error
+	live   bool
+	index  int
+	cursor interface{}
+	// seen counts the rows of the history with the last cursor by fingerprint, skip is
+	// what is left to drop from the live phase.
+	seen map[string]int
+	skip map[string]int
+	// shard is the index of _tp_shard and shards the last sequence number of each shard,
+	// with the _tp_sn cursor.
+	shard     int
+	shards    map[int64]int64
+	header    *proto.Block
+	current   driver.Rows
+	row       int
+	block     *proto.Block
+	structMap structMap
+}
+
+// query is the query of the current phase.
+func (t *tailRows) query() string {
+	var (
+		columns = "*"
+		cursor  = lexer.QuoteIdentifier(t.opts.Cursor)
+		from    string
+		where   []string
+	)
+	if len(t.opts.Columns) != 0 {
+		quoted := make([]string, 0, len(t.opts.Columns))
+		for _, name := range t.opts.Columns {
+			quoted = append(quoted, lexer.QuoteIdentifier(name))
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+	for _, name := range t.extra() {
+		columns += ", " + lexer.QuoteIdentifier(name)
+	}
+	switch database, stream := splitStreamName(t.stream); database {
+	case "":
+		from = lexer.QuoteIdentifier(stream)
+	default:
+		from = lexer.QuoteIdentifier(database) + "." + lexer.QuoteIdentifier(stream)
+	}
+	if !t.since.IsZero() {
+		where = append(where, "_tp_time >= "+cursorLiteral(t.since))
+	}
+	switch {
+	case !t.live:
+	case t.sharded() && len(t.shards) != 0:
+		where = append(where, t.shardsCondition())
+	case !t.sharded() && t.cursor != nil:
+		where = append(where, cursor+" >= "+cursorLiteral(t.cursor))
+	}
+	if t.opts.Where != "" {
+		where = append(where, "("+t.opts.Where+")")
+	}
+	if !t.live {
+		from = "table(" + from + ")"
+	}
+	query := "SELECT " + columns + " FROM " + from
+	if len(where) != 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if !t.live {
+		query += " ORDER BY " + cursor
+	}
+	return query
+}
+
+// extra are the cursor columns the phases are stitched on that are not in Columns. They are
+// read after the columns of the feed and stripped from its blocks.
+func (t *tailRows) extra() []string {
+	var extra []string
+	if !contains(t.opts.Columns, t.opts.Cursor) {
+		extra = append(extra, t.opts.Cursor)
+	}
+	if t.sharded() && !contains(t.opts.Columns, "_tp_shard") {
+		extra = append(extra, "_tp_shard")
+	}
+	return extra
+}
+
+// sharded reports whether the cursor is _tp_sn, whose sequence numbers are per shard.
+func (t *tailRows) sharded() bool {
+	return t.opts.Cursor == "_tp_sn"
+}
+
+// shardsCondition filters out the rows of the shards up to their last sequence number in the
+// history, the shards that had no rows are read from the start.
+func (t *tailRows) shardsCondition() string {
+	shards := make([]int64, 0, len(t.shards))
+	for shard := range t.shards {
+		shards = append(shards, shard)
+	}
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	var (
+		conditions = make([]string, 0, len(shards)+1)
+		list       = make([]string, 0, len(shards))
+	)
+	for _, shard := range shards {
+		conditions = append(conditions, fmt.Sprintf("(`_tp_shard` = %d AND `_tp_sn` > %d)", shard, t.shards[shard]))
+		list = append(list, strconv.FormatInt(shard, 10))
+	}
+	conditions = append(conditions, "`_tp_shard` NOT IN ("+strings.Join(list, ", ")+")")
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// remember keeps the last cursor of the history: the last sequence number of every shard
+// with _tp_sn, the last value and the rows that have it otherwise.
+func (t *tailRows) remember(block *proto.Block) error {
+	cursor := block.Columns[t.index]
+	if t.sharded() {
+		if t.shards == nil {
+			t.shards = make(map[int64]int64)
+		}
+		shard := block.Columns[t.shard]
+		for i := 0; i < block.Rows(); i++ {
+			s, ok1 := toInt64(shard.Row(i, false))
+			sn, ok2 := toInt64(cursor.Row(i, false))
+			if !ok1 || !ok2 {
+				return &OpError{
+					Op:  "Tail",
+					Err: fmt.Errorf("unexpected types of _tp_shard and _tp_sn: %s, %s", shard.Type(), cursor.Type()),
+				}
+			}
+			if last, ok := t.shards[s]; !ok || sn > last {
+				t.shards[s] = sn
+			}
+		}
+		return nil
+	}
+	rows := block.Rows()
+	if last := cursor.Row(rows-1, false); t.seen == nil || !cursorEqual(last, t.cursor) {
+		t.cursor, t.seen = last, make(map[string]int)
+	}
+	for i := rows - 1; i >= 0 && cursorEqual(cursor.Row(i, false), t.cursor); i-- {
+		t.seen[fingerprint(block, i)]++
+	}
+	return nil
+}
+
+// dedup drops the rows of the live phase that were read in the history with its last cursor.
+func (t *tailRows) dedup(block *proto.Block) (*proto.Block, error) {
+	if len(t.skip) == 0 {
+		return block, nil
+	}
+	var (
+		cursor = block.Columns[t.index]
+		keep   = make([]int, 0, block.Rows())
+	)
+	for i := 0; i < block.Rows(); i++ {
+		if cursorEqual(cursor.Row(i, false), t.cursor) {
+			if key := fingerprint(block, i); t.skip[key] > 0 {
+				t.skip[key]--
+				continue
+			}
+		}
+		keep = append(keep, i)
+	}
+	return filterBlock(block, keep)
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// follow starts the live phase once the history is read.
+func (t *tailRows) follow() error {
+	if err := t.current.Close(); err != nil {
+		return err
+	}
+	t.current, t.live, t.skip = nil, true, t.seen
+	var (
+		options  = queryOptions(t.ctx)
+		settings = make(Settings, len(options.settings)+1)
+	)
+	for k, v := range options.settings {
+		settings[k] = v
+	}
+	settings["seek_to"] = "earliest"
+	if !t.since.IsZero() {
+		settings["seek_to"] = t.since.UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	options.settings = settings
+	current, err := t.ch.Query(context.WithValue(t.ctx, _contextOptionKey, options), t.query(), t.opts.Args...)
+	if err != nil {
+		return err
+	}
+	t.current = current
+	return nil
+}
+
+// fetch returns the next block with rows, remembering the cursor of the history.
+func (t *tailRows) fetch() (*proto.Block, error) {
+	for {
+		if t.current == nil {
+			return nil, io.EOF
+		}
+		reader, ok := t.current.(driver.BlockReader)
+		if !ok {
+			return nil, &OpError{
+				Op:  "Tail",
+				Err: fmt.Errorf("%T does not implement driver.BlockReader", t.current),
+			}
+		}
+		block, err := reader.NextBlock()
+		switch {
+		case errors.Is(err, io.EOF) && !t.live:
+			if err := t.follow(); err != nil {
+				return nil, err
+			}
+			continue
+		case err != nil:
+			return nil, err
+		case block.Rows() == 0:
+			continue
+		}
+		if !t.live {
+			if err := t.remember(block); err != nil {
+				return nil, err
+			}
+			return block.Head(len(t.header.Columns)), nil
+		}
+		if block, err = t.dedup(block); err != nil {
+			return nil, err
+		}
+		if block.Rows() != 0 {
+			return block.Head(len(t.header.Columns)), nil
+		}
+	}
+}
+
+func (t *tailRows) Next() bool {
+	for t.block == nil || t.row >= t.block.Rows() {
+		block, err := t.fetch()
+		if err != nil {
+			t.fail(err)
+			return false
+		}
+		t.row, t.block = 0, block
+	}
+	t.row++
+	return true
+}
+
+func (t *tailRows) NextBlock() (*proto.Block, error) {
+	block, err := t.fetch()
+	if err != nil {
+		t.fail(err)
+		if t.err != nil {
+			return nil, t.err
+		}
+		return nil, io.EOF
+	}
+	t.row, t.block = block.Rows(), block
+	return block, nil
+}
+
+func (t *tailRows) Scan(dest ...interface{}) error {
+	if t.block == nil || t.row == 0 {
+		return io.EOF
+	}
+	return scan(t.block, t.row, dest...)
+}
+
+func (t *tailRows) ScanStruct(dest interface{}) error {
+	values, err := t.structMap.Map("ScanStruct", t.Columns(), dest, true)
+	if err != nil {
+		return err
+	}
+	return t.Scan(values...)
+}
+
+func (t *tailRows) ColumnTypes() []driver.ColumnType {
+	return columnTypes(t.header)
+}
+
+func (t *tailRows) Totals(dest ...interface{}) error {
+	return sql.ErrNoRows
+}
+
+func (t *tailRows) Columns() []string {
+	return t.header.ColumnsNames()
+}
+
+// QueryID is the ID of the query of the current phase.
+func (t *tailRows) QueryID() string {
+	if current, ok := t.current.(driver.QueryIdentifier); ok {
+		return current.QueryID()
+	}
+	return ""
+}
+
+func (t *tailRows) fail(err error) {
+	if !errors.Is(err, io.EOF) {
+		t.err = err
+	}
+	t.Close()
+}
+
+func (t *tailRows) Close() error {
+	if t.current != nil {
+		if err := t.current.Close(); err != nil && t.err == nil {
+			t.err = err
+		}
+	}
+	return t.err
+}
+
+func (t *tailRows) Err() error {
+	return t.err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ (driver.Tailer)          = (*proton)(nil)
+	_ (driver.Rows)            = (*tailRows)(nil)
+	_ (driver.BlockReader)     = (*tailRows)(nil)
+	_ (driver.QueryIdentifier) = (*tailRows)(nil)
+)
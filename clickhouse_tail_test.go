@@ -0,0 +1,213 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestTail(t *testing.T) {
+	var queries []*protontest.Query
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		queries = append(queries, q)
+		block := func(sns ...int64) *proto.Block {
+			var block proto.Block
+			block.AddColumn("id", "string")
+			block.AddColumn("_tp_sn", "int64")
+			block.AddColumn("_tp_shard", "int32")
+			for _, sn := range sns {
+				block.Append("id"+string(rune('0'+sn)), sn, int32(0))
+			}
+			return &block
+		}
+		if err := w.Data(block()); err != nil {
+			return err
+		}
+		if strings.Contains(q.Body, "table(") {
+			if err := w.Data(block(1, 2)); err != nil {
+				return err
+			}
+			return w.Data(block(3))
+		}
+		return w.Data(block(4, 5))
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows, err := conn.(Tailer).Tail(context.Background(), "db.events", since, TailOptions{
+		Columns: []string{"id"},
+		Where:   "id != $1",
+		Args:    []interface{}{"x"},
+	})
+	require.NoError(t, err)
+	// the cursor columns are not in the feed
+	assert.Equal(t, []string{"id"}, rows.Columns())
+	var ids []string
+	for rows.Next() {
+		var event struct {
+			ID string `ch:"id"`
+		}
+		require.NoError(t, rows.ScanStruct(&event))
+		ids = append(ids, event.ID)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"id1", "id2", "id3", "id4", "id5"}, ids)
+
+	if assert.Len(t, queries, 2) {
+		assert.Equal(t, "SELECT `id`, `_tp_sn`, `_tp_shard` FROM table(`db`.`events`)"+
+			" WHERE _tp_time >= to_datetime64('2024-01-02 03:04:05', 9, 'UTC') AND (id != 'x') ORDER BY `_tp_sn`", queries[0].Body)
+		assert.Equal(t, "SELECT `id`, `_tp_sn`, `_tp_shard` FROM `db`.`events`"+
+			" WHERE _tp_time >= to_datetime64('2024-01-02 03:04:05', 9, 'UTC')"+
+			" AND ((`_tp_shard` = 0 AND `_tp_sn` > 3) OR `_tp_shard` NOT IN (0)) AND (id != 'x')", queries[1].Body)
+		assert.Equal(t, "2024-01-02T03:04:05.000Z", queries[1].Settings["seek_to"])
+	}
+}
+
+func TestTailCursor(t *testing.T) {
+	var queries []string
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		queries = append(queries, q.Body)
+		var block proto.Block
+		block.AddColumn("id", "string")
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.(Tailer).Tail(context.Background(), "events", time.Time{}, TailOptions{})
+	var opErr *OpError
+	if assert.ErrorAs(t, err, &opErr) {
+		assert.Equal(t, "Tail", opErr.Op)
+	}
+	assert.Equal(t, []string{"SELECT *, `_tp_sn`, `_tp_shard` FROM table(`events`) ORDER BY `_tp_sn`"}, queries)
+}
+
+func TestTailShards(t *testing.T) {
+	var queries []string
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		queries = append(queries, q.Body)
+		var block proto.Block
+		block.AddColumn("_tp_sn", "int64")
+		block.AddColumn("_tp_shard", "int32")
+		if strings.Contains(q.Body, "table(") {
+			// the sequence numbers of the shards interleave in the history
+			for _, row := range [][2]int64{{1, 0}, {1, 1}, {2, 0}, {2, 1}, {3, 0}, {4, 0}} {
+				block.Append(row[0], int32(row[1]))
+			}
+			return w.Data(&block)
+		}
+		// shard 1 is behind shard 0, its rows are not dropped
+		for _, row := range [][2]int64{{3, 1}, {5, 0}, {1, 2}} {
+			block.Append(row[0], int32(row[1]))
+		}
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rows, err := conn.(Tailer).Tail(context.Background(), "events", time.Time{}, TailOptions{
+		Columns: []string{"_tp_sn", "_tp_shard"},
+	})
+	require.NoError(t, err)
+	var got [][2]int64
+	for rows.Next() {
+		var (
+			sn    int64
+			shard int32
+		)
+		require.NoError(t, rows.Scan(&sn, &shard))
+		got = append(got, [2]int64{sn, int64(shard)})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][2]int64{{1, 0}, {1, 1}, {2, 0}, {2, 1}, {3, 0}, {4, 0}, {3, 1}, {5, 0}, {1, 2}}, got)
+	if assert.Len(t, queries, 2) {
+		assert.Equal(t, "SELECT `_tp_sn`, `_tp_shard` FROM `events` WHERE ((`_tp_shard` = 0 AND `_tp_sn` > 4)"+
+			" OR (`_tp_shard` = 1 AND `_tp_sn` > 2) OR `_tp_shard` NOT IN (0, 1))", queries[1])
+	}
+}
+
+func TestTailTies(t *testing.T) {
+	var (
+		queries []string
+		t0      = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		t1      = t0.Add(time.Second)
+	)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		queries = append(queries, q.Body)
+		block := func(rows ...interface{}) *proto.Block {
+			var block proto.Block
+			block.AddColumn("id", "string")
+			block.AddColumn("_tp_time", "datetime64(3, 'UTC')")
+			for i := 0; i < len(rows); i += 2 {
+				block.Append(rows[i], rows[i+1])
+			}
+			return &block
+		}
+		if strings.Contains(q.Body, "table(") {
+			// the history ends with two rows tied on the cursor
+			if err := w.Data(block("a", t0, "b", t0)); err != nil {
+				return err
+			}
+			return w.Data(block("c", t1, "d", t1))
+		}
+		// the live phase reads the tied rows again with one more written at the same time
+		if err := w.Data(block("d", t1, "e", t1)); err != nil {
+			return err
+		}
+		return w.Data(block("c", t1, "f", t1.Add(time.Second)))
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	rows, err := conn.(Tailer).Tail(context.Background(), "events", time.Time{}, TailOptions{
+		Columns: []string{"id", "_tp_time"},
+		Cursor:  "_tp_time",
+	})
+	require.NoError(t, err)
+	var ids []string
+	for rows.Next() {
+		var (
+			id string
+			ts time.Time
+		)
+		require.NoError(t, rows.Scan(&id, &ts))
+		ids = append(ids, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, ids)
+	if assert.Len(t, queries, 2) {
+		assert.Equal(t, "SELECT `id`, `_tp_time` FROM `events` WHERE `_tp_time` >= to_datetime64('2024-01-02 03:04:06', 9, 'UTC')", queries[1])
+	}
+}
@@ -0,0 +1,182 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	OperationStats = driver.OperationStats
+	OperationInfo  = driver.OperationInfo
+)
+
+// Instrumentation observes the driver, the otel package implements it with OpenTelemetry.
+// Start is called when a dial, a handshake or an operation starts and the function it returns
+// once when it ends. A span started in the returned context is sent to the server as the parent
+// of the query, unless one is set with WithSpan.
+type Instrumentation interface {
+	Start(ctx context.Context, info OperationInfo) (context.Context, func(OperationStats))
+}
+
+// PoolObserver is implemented by the instrumentations that observe the pool, Open calls
+// ObservePool and Close the function it returns.
+type PoolObserver interface {
+	ObservePool(stats func() driver.Stats) (stop func())
+}
+
+// instrument starts a dial or a handshake, they are only seen by Options.Instrumentation.
+func (o *Options) instrument(ctx context.Context, name, addr string) (context.Context, func(error)) {
+	if o.Instrumentation == nil {
+		return ctx, func(error) {}
+	}
+	start := time.Now()
+	ctx, done := o.Instrumentation.Start(ctx, OperationInfo{
+		Operation: name,
+		Addr:      addr,
+		Database:  o.Auth.Database,
+	})
+	return ctx, func(err error) {
+		done(OperationStats{
+			Operation: name,
+			Duration:  time.Since(start),
+			Err:       err,
+		})
+	}
+}
+
+// operation measures a query, exec, stream, batch or ping from start to the release of the connection.
+type operation struct {
+	name     string
+	queryID  string
+	conn     *connect
+	start    time.Time
+	counters io.Counters
+	progress Progress
+	profile  *ProfileInfo
+	rows     int
+	ended    bool
+	done     func(OperationStats)
+}
+
+// startOperation starts measuring an operation on c. When the instrumentation starts a span and
+// the caller did not pass one with WithSpan, it becomes the parent of the query on the server.
+func (c *connect) startOperation(ctx context.Context, name, query string, options *QueryOptions) *operation {
+	if c.opt.Instrumentation == nil && c.opt.OnOperation == nil {
+		return nil
+	}
+	op := operation{
+		name:     name,
+		conn:     c,
+		start:    time.Now(),
+		counters: c.stream.Counters(),
+	}
+	if options != nil {
+		op.queryID = options.queryID
+	}
+	if c.opt.Instrumentation != nil {
+		parent := trace.SpanContextFromContext(ctx)
+		ctx, op.done = c.opt.Instrumentation.Start(ctx, OperationInfo{
+			Operation: name,
+			QueryID:   op.queryID,
+			Query:     query,
+			Addr:      c.conn.RemoteAddr().String(),
+			Database:  c.opt.Auth.Database,
+		})
+		if span := trace.SpanContextFromContext(ctx); span.IsValid() && !span.Equal(parent) && options != nil && !options.span.IsValid() {
+			options.span = span
+		}
+	}
+	return &op
+}
+
+// observe chains the progress and profile info handlers of on to the operation.
+func (op *operation) observe(on *onProcess) {
+	if op == nil {
+		return
+	}
+	var (
+		progress    = on.progress
+		profileInfo = on.profileInfo
+	)
+	on.progress = func(p *Progress) {
+		op.progress.Rows += p.Rows
+		op.progress.Bytes += p.Bytes
+		op.progress.TotalRows += p.TotalRows
+		op.progress.WroteRows += p.WroteRows
+		op.progress.WroteBytes += p.WroteBytes
+		progress(p)
+	}
+	on.profileInfo = func(p *ProfileInfo) {
+		op.profile = p
+		profileInfo(p)
+	}
+}
+
+func (op *operation) end(err error) {
+	if op == nil || op.ended {
+		return
+	}
+	op.ended = true
+	stats := OperationStats{
+		Operation: op.name,
+		QueryID:   op.queryID,
+		Duration:  time.Since(op.start),
+		Progress:  op.progress,
+		Profile:   op.profile,
+		BatchRows: op.rows,
+		Err:       err,
+	}
+	if op.conn.stream != nil {
+		after := op.conn.stream.Counters()
+		stats.Network = io.Counters{
+			WireRead:    after.WireRead - op.counters.WireRead,
+			WireWritten: after.WireWritten - op.counters.WireWritten,
+			DataRead:    after.DataRead - op.counters.DataRead,
+			DataWritten: after.DataWritten - op.counters.DataWritten,
+		}
+	}
+	if fn := op.conn.opt.OnOperation; fn != nil {
+		fn(stats)
+	}
+	if op.done != nil {
+		op.done(stats)
+	}
+}
+
+func (op *operation) setRows(rows int) {
+	if op != nil {
+		op.rows = rows
+	}
+}
+
+// release wraps the release function of a connection to end the operation first.
+func (op *operation) release(release func(*connect, error)) func(*connect, error) {
+	if op == nil {
+		return release
+	}
+	return func(c *connect, err error) {
+		op.end(err)
+		release(c, err)
+	}
+}
@@ -0,0 +1,187 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recorder is an Instrumentation that records the operations and starts a span for each one.
+type recorder struct {
+	started []OperationInfo
+	ended   []OperationStats
+	span    trace.SpanContext
+	stopped bool
+}
+
+func (r *recorder) Start(ctx context.Context, info OperationInfo) (context.Context, func(OperationStats)) {
+	r.started = append(r.started, info)
+	if r.span.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, r.span)
+	}
+	return ctx, func(stats OperationStats) {
+		r.ended = append(r.ended, stats)
+	}
+}
+
+func (r *recorder) ObservePool(stats func() driver.Stats) func() {
+	return func() {
+		r.stopped = true
+	}
+}
+
+func TestInstrumentationPing(t *testing.T) {
+	var (
+		r   recorder
+		opt = &Options{Instrumentation: &r}
+	)
+	opt.setDefaults()
+	client, server := net.Pipe()
+	defer server.Close()
+	stream := io.NewStream(client)
+	c := &connect{
+		opt:     opt,
+		conn:    client,
+		stream:  stream,
+		encoder: binary.NewEncoder(stream),
+		decoder: binary.NewDecoder(stream),
+	}
+	defer c.close()
+	go func() {
+		packet := make([]byte, 1)
+		if _, err := server.Read(packet); err == nil && packet[0] == proto.ClientPing {
+			server.Write([]byte{proto.ServerPong})
+		}
+	}()
+	require.NoError(t, c.ping(context.Background()))
+
+	if assert.Len(t, r.started, 1) {
+		assert.Equal(t, "ping", r.started[0].Operation)
+		assert.Equal(t, "pipe", r.started[0].Addr)
+	}
+	if assert.Len(t, r.ended, 1) {
+		assert.Equal(t, "ping", r.ended[0].Operation)
+		assert.NoError(t, r.ended[0].Err)
+		assert.Equal(t, io.Counters{WireRead: 1, WireWritten: 1, DataRead: 1, DataWritten: 1}, r.ended[0].Network)
+	}
+}
+
+func TestInstrumentationDisabled(t *testing.T) {
+	opt := &Options{}
+	opt.setDefaults()
+	c := &connect{opt: opt}
+	op := c.startOperation(context.Background(), "query", "SELECT 1", &QueryOptions{})
+	assert.Nil(t, op)
+	// a nil operation is a no-op
+	op.observe(&onProcess{})
+	op.setRows(1)
+	op.end(nil)
+	released := false
+	op.release(func(*connect, error) { released = true })(c, nil)
+	assert.True(t, released)
+	_, end := opt.instrument(context.Background(), "dial", "127.0.0.1:8463")
+	end(nil)
+}
+
+func TestOnOperation(t *testing.T) {
+	var observed []OperationStats
+	opt := &Options{
+		OnOperation: func(stats OperationStats) {
+			observed = append(observed, stats)
+		},
+	}
+	opt.setDefaults()
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &connect{
+		opt:    opt,
+		conn:   client,
+		stream: io.NewStream(client),
+	}
+	defer c.close()
+	options := QueryOptions{queryID: "q1"}
+	op := c.startOperation(context.Background(), "query", "SELECT 1", &options)
+	require.NotNil(t, op)
+	on := &onProcess{progress: func(*Progress) {}, profileInfo: func(*ProfileInfo) {}}
+	op.observe(on)
+	on.progress(&Progress{Rows: 2, Bytes: 16})
+	on.progress(&Progress{Rows: 3, Bytes: 24})
+	on.profileInfo(&ProfileInfo{Rows: 5})
+	op.end(&Exception{Code: 60})
+	op.end(nil)
+	if assert.Len(t, observed, 1) {
+		assert.Equal(t, "query", observed[0].Operation)
+		assert.Equal(t, "q1", observed[0].QueryID)
+		assert.Equal(t, uint64(5), observed[0].Progress.Rows)
+		assert.Equal(t, uint64(40), observed[0].Progress.Bytes)
+		assert.Equal(t, uint64(5), observed[0].Profile.Rows)
+		assert.Error(t, observed[0].Err)
+	}
+}
+
+func TestInstrumentationPropagatesSpan(t *testing.T) {
+	var (
+		span = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{2},
+			TraceFlags: trace.FlagsSampled,
+		})
+		r   = recorder{span: span}
+		opt = &Options{Instrumentation: &r}
+	)
+	opt.setDefaults()
+	client, server := net.Pipe()
+	defer server.Close()
+	c := &connect{
+		opt:    opt,
+		conn:   client,
+		stream: io.NewStream(client),
+	}
+	defer c.close()
+	var options QueryOptions
+	op := c.startOperation(context.Background(), "query", "SELECT 1", &options)
+	require.NotNil(t, op)
+	assert.Equal(t, span, options.span)
+	op.end(nil)
+
+	// the span of the caller is not replaced when the instrumentation starts none
+	r.span = trace.SpanContext{}
+	caller := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{3}, SpanID: trace.SpanID{4}})
+	options = QueryOptions{}
+	op = c.startOperation(trace.ContextWithSpanContext(context.Background(), caller), "query", "SELECT 1", &options)
+	assert.False(t, options.span.IsValid())
+	op.end(nil)
+}
+
+func TestInstrumentationObservesPool(t *testing.T) {
+	var r recorder
+	conn, err := Open(&Options{Instrumentation: &r})
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	assert.True(t, r.stopped)
+}
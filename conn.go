@@ -20,11 +20,10 @@ package proton
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
-	"log"
 	"net"
-	"os"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
@@ -32,61 +31,94 @@ import (
 	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
 )
 
-func dial(ctx context.Context, addr string, num int, opt *Options) (*connect, error) {
-	var (
-		err    error
-		conn   net.Conn
-		debugf = func(format string, v ...interface{}) {}
-	)
-	switch {
-	case opt.DialContext != nil:
-		conn, err = opt.DialContext(ctx, addr)
-	default:
-		switch {
-		case opt.TLS != nil:
-			conn, err = tls.DialWithDialer(&net.Dialer{Timeout: opt.DialTimeout}, "tcp", addr, opt.TLS)
-		default:
-			conn, err = net.DialTimeout("tcp", addr, opt.DialTimeout)
-		}
-	}
+func dial(ctx context.Context, addr string, num int, opt *Options) (_ *connect, err error) {
+	ctx, end := opt.instrument(ctx, "dial", addr)
+	defer func() {
+		end(err)
+	}()
+	conn, err := dialConn(ctx, addr, opt)
 	if err != nil {
 		return nil, err
 	}
-	if opt.Debug {
-		debugf = log.New(os.Stdout, fmt.Sprintf("[proton][conn=%d][%s]", num, conn.RemoteAddr()), 0).Printf
+	connect := newConnect(conn, num, opt)
+	if opt.capture != nil {
+		connect.stream.Capture(opt.capture.Session(addr))
 	}
-	var compression bool
-	if opt.Compression != nil {
-		compression = opt.Compression.Method == CompressionLZ4
+	_, handshake := opt.instrument(ctx, "handshake", addr)
+	err = connect.handshake(opt.Auth.Database, opt.Auth.Username, opt.Auth.Password)
+	if handshake(err); err != nil {
+		connect.close()
+		return nil, err
+	}
+	return connect, nil
+}
+
+// probe checks that a host answers a ping over a new connection. Unlike dial it is not
+// traced, captured or counted in the stats.
+func probe(ctx context.Context, addr string, opt *Options) error {
+	conn, err := dialConn(ctx, addr, opt)
+	if err != nil {
+		return err
 	}
+	connect := newConnect(conn, 0, opt)
+	defer connect.close()
+	if err := connect.handshake(opt.Auth.Database, opt.Auth.Username, opt.Auth.Password); err != nil {
+		return err
+	}
+	return connect.sendPing(ctx)
+}
+
+func dialConn(ctx context.Context, addr string, opt *Options) (net.Conn, error) {
+	switch {
+	case opt.DialContext != nil:
+		return opt.DialContext(ctx, addr)
+	case opt.TLS != nil:
+		return tls.DialWithDialer(&net.Dialer{Timeout: opt.DialTimeout}, "tcp", addr, opt.TLS)
+	}
+	return net.DialTimeout("tcp", addr, opt.DialTimeout)
+}
+
+func newConnect(conn net.Conn, num int, opt *Options) *connect {
 	var (
-		stream  = io.NewStream(conn)
-		connect = &connect{
-			opt:      opt,
-			conn:     conn,
-			debugf:   debugf,
-			stream:   stream,
-			encoder:  binary.NewEncoder(stream),
-			decoder:  binary.NewDecoder(stream),
-			revision: proto.ClientTCPProtocolVersion,
-			structMap: structMap{
-				cache: make(map[reflect.Type]map[string][]int),
-			},
-			compression: compression,
-			connectedAt: time.Now(),
-		}
+		compression bool
+		method      = CompressionLZ4
+		level       int
 	)
-	if err := connect.handshake(opt.Auth.Database, opt.Auth.Username, opt.Auth.Password); err != nil {
-		return nil, err
+	if opt.Compression != nil {
+		switch opt.Compression.Method {
+		case CompressionLZ4, CompressionZSTD:
+			compression = true
+			method, level = opt.Compression.Method, opt.CompressionLevel
+		}
+	}
+	// the revisions after DBMS_TCP_PROTOCOL_VERSION are only negotiated for query parameters
+	revision := uint64(proto.ClientTCPProtocolVersion)
+	if opt.ServerSideBinding {
+		revision = proto.DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS
+	}
+	stream := io.NewStreamLevel(conn, method, level)
+	return &connect{
+		opt:      opt,
+		id:       num,
+		conn:     conn,
+		stream:   stream,
+		encoder:  binary.NewEncoder(stream),
+		decoder:  binary.NewDecoder(stream),
+		revision: revision,
+		structMap: structMap{
+			cache: make(map[reflect.Type]map[string][]int),
+		},
+		compression: compression,
+		connectedAt: time.Now(),
 	}
-	return connect, nil
 }
 
 // https://github.com/ClickHouse/ClickHouse/blob/master/src/Client/Connection.cpp
 type connect struct {
+	id          int
 	opt         *Options
 	conn        net.Conn
-	debugf      func(format string, v ...interface{})
+	queryID     string
 	server      ServerVersion
 	stream      *io.Stream
 	closed      bool
@@ -96,12 +128,32 @@ type connect struct {
 	revision    uint64
 	structMap   structMap
 	compression bool
-	//lastUsedIn  time.Time
+	host        *host
+	lastUsedIn  time.Time
 	connectedAt time.Time
+	// kill runs KILL QUERY from another connection, see cancel.
+	kill         func(ctx context.Context, queryID string) error
+	cancelMu     sync.Mutex
+	killer       *time.Timer
+	canceled     bool
+	acknowledged bool
 }
 
 func (c *connect) settings(querySettings Settings) []proto.Setting {
-	settings := make([]proto.Setting, 0, len(c.opt.Settings)+len(querySettings))
+	settings := make([]proto.Setting, 0, len(c.opt.Settings)+len(querySettings)+2)
+	if c.compression && c.opt.Compression.Method == CompressionZSTD {
+		// ask the server to answer with ZSTD too, explicit settings below take precedence
+		settings = append(settings, proto.Setting{
+			Key:   "network_compression_method",
+			Value: "zstd",
+		})
+		if c.opt.CompressionLevel > 0 {
+			settings = append(settings, proto.Setting{
+				Key:   "network_zstd_compression_level",
+				Value: c.opt.CompressionLevel,
+			})
+		}
+	}
 	for k, v := range c.opt.Settings {
 		settings = append(settings, proto.Setting{
 			Key:   k,
@@ -118,8 +170,11 @@ func (c *connect) settings(querySettings Settings) []proto.Setting {
 }
 
 func (c *connect) isBad() bool {
+	c.cancelMu.Lock()
+	canceled := c.canceled
+	c.cancelMu.Unlock()
 	switch {
-	case c.closed:
+	case c.closed, canceled:
 		return true
 	}
 	if err := c.connCheck(); err != nil {
@@ -133,6 +188,9 @@ func (c *connect) close() error {
 		return nil
 	}
 	c.closed = true
+	if c.host != nil {
+		atomic.AddInt64(&c.host.open, -1)
+	}
 	c.encoder = nil
 	c.decoder = nil
 	c.stream.Close()
@@ -147,7 +205,7 @@ func (c *connect) progress() (*Progress, error) {
 	if err := progress.Decode(c.decoder, c.revision); err != nil {
 		return nil, err
 	}
-	c.debugf("[progress] %s", &progress)
+	c.debug("progress", "packet", "progress", "rows", progress.Rows, "bytes", progress.Bytes, "total_rows", progress.TotalRows, "wrote_rows", progress.WroteRows, "wrote_bytes", progress.WroteBytes)
 	return &progress, nil
 }
 
@@ -156,41 +214,78 @@ func (c *connect) exception() error {
 	if err := e.Decode(c.decoder); err != nil {
 		return err
 	}
-	c.debugf("[exception] %s", e.Error())
+	c.debug("exception", "packet", "exception", "code", e.Code, "name", e.Name, "message", e.Message)
+	e.QueryID = c.queryID
 	return &e
 }
 
+// readPacket reads the type of the next packet of the server.
+func (c *connect) readPacket() (byte, error) {
+	packet, err := c.decoder.ReadByte()
+	if err == nil {
+		c.stream.Mark(io.RecordPacketRead, packet)
+	}
+	return packet, err
+}
+
+func (c *connect) writePacket(packet byte) error {
+	if err := c.encoder.Byte(packet); err != nil {
+		return err
+	}
+	c.stream.Mark(io.RecordPacketWrite, packet)
+	return nil
+}
+
 func (c *connect) sendData(block *proto.Block, name string) error {
-	c.debugf("[send data] compression=%t", c.compression)
-	if err := c.encoder.Byte(proto.ClientData); err != nil {
+	c.debug("send data", "compression", c.compression, "columns", len(block.Columns), "rows", block.Rows())
+	if err := c.writePacket(proto.ClientData); err != nil {
 		return err
 	}
 	if err := c.encoder.String(name); err != nil {
 		return err
 	}
 	if c.compression {
-		c.stream.Compress(true)
+		c.stream.CompressWrite(true)
 		defer func() {
-			c.stream.Compress(false)
+			c.stream.CompressWrite(false)
 			c.encoder.Flush()
 		}()
 	}
 	return block.Encode(c.encoder, c.revision)
 }
 
+// sendEncodedData sends a block that was encoded beforehand by encodeBlock.
+func (c *connect) sendEncodedData(data []byte) error {
+	c.debug("send encoded data", "compression", c.compression, "size", len(data))
+	if err := c.writePacket(proto.ClientData); err != nil {
+		return err
+	}
+	if err := c.encoder.String(""); err != nil {
+		return err
+	}
+	if c.compression {
+		c.stream.CompressWrite(true)
+		defer func() {
+			c.stream.CompressWrite(false)
+			c.encoder.Flush()
+		}()
+	}
+	return c.encoder.Raw(data)
+}
+
 func (c *connect) readData(packet byte, compressible bool) (*proto.Block, error) {
 	if _, err := c.decoder.String(); err != nil {
 		return nil, err
 	}
 	if compressible && c.compression {
-		c.stream.Compress(true)
-		defer c.stream.Compress(false)
+		c.stream.CompressRead(true)
+		defer c.stream.CompressRead(false)
 	}
 	var block proto.Block
 	if err := block.Decode(c.decoder, c.revision); err != nil {
 		return nil, err
 	}
 	block.Packet = packet
-	c.debugf("[read data] compression=%t. block: columns=%d, rows=%d", c.compression, len(block.Columns), block.Rows())
+	c.debug("read data", "packet", packetName(packet), "compression", c.compression, "columns", len(block.Columns), "rows", block.Rows())
 	return &block, nil
 }
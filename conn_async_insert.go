@@ -21,8 +21,16 @@ import (
 	"context"
 )
 
-func (c *connect) asyncInsert(ctx context.Context, query string, wait bool) error {
-	options := queryOptions(ctx)
+func (c *connect) asyncInsert(ctx context.Context, query string, wait bool) (err error) {
+	var (
+		options   = c.queryOptions(ctx)
+		onProcess = options.onProcess()
+		op        = c.startOperation(ctx, "async_insert", query, &options)
+	)
+	op.observe(onProcess)
+	defer func() {
+		op.end(err)
+	}()
 	{
 		options.settings["async_insert"] = 1
 		options.settings["wait_for_async_insert"] = 0
@@ -33,5 +41,5 @@ func (c *connect) asyncInsert(ctx context.Context, query string, wait bool) erro
 	if err := c.sendQuery(query, &options); err != nil {
 		return err
 	}
-	return c.process(ctx, options.onProcess())
+	return c.process(ctx, onProcess)
 }
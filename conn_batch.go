@@ -21,23 +21,50 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
 )
 
-var splitInsertRe = regexp.MustCompile(`(?i)\sVALUES\s*\(`)
+// insertQuery returns the INSERT of a batch without its values: the query up to its first VALUES
+// keyword outside of parentheses, literals and comments, followed by VALUES.
+func insertQuery(query string) string {
+	var (
+		end   int
+		depth int
+	)
+	for _, t := range lexer.Tokenize(query) {
+		switch {
+		case t.Kind == lexer.Space, t.Kind == lexer.Comment:
+			continue
+		case t.Kind == lexer.Punct && t.Text == "(":
+			depth++
+		case t.Kind == lexer.Punct && t.Text == ")":
+			depth--
+		case t.Kind == lexer.Punct && t.Text == ";" && depth == 0:
+			return query[:end] + " VALUES"
+		case t.Kind == lexer.Word && depth == 0 && strings.EqualFold(t.Text, "VALUES"):
+			return query[:end] + " VALUES"
+		}
+		end = t.Pos + len(t.Text)
+	}
+	return query[:end] + " VALUES"
+}
 
 func (c *connect) prepareBatch(ctx context.Context, query string, release func(*connect, error)) (*batch, error) {
-	query = splitInsertRe.Split(query, -1)[0]
-	if !strings.HasSuffix(strings.TrimSpace(strings.ToUpper(query)), "VALUES") {
-		query += " VALUES"
-	}
-	options := queryOptions(ctx)
+	query = insertQuery(query)
+	var (
+		options   = c.queryOptions(ctx)
+		onProcess = options.onProcess()
+		result    = newExecResult(options.queryID, onProcess)
+		op        = c.startOperation(ctx, "batch.send", query, &options)
+	)
+	op.observe(onProcess)
+	release = op.release(release)
 	if deadline, ok := ctx.Deadline(); ok {
 		c.conn.SetDeadline(deadline)
 		defer c.conn.SetDeadline(time.Time{})
@@ -46,21 +73,21 @@ func (c *connect) prepareBatch(ctx context.Context, query string, release func(*
 		release(c, err)
 		return nil, err
 	}
-	var (
-		onProcess  = options.onProcess()
-		block, err = c.firstBlock(ctx, onProcess)
-	)
+	block, err := c.firstBlock(ctx, onProcess)
 	if err != nil {
 		release(c, err)
 		return nil, err
 	}
 	return &batch{
 		ctx:   ctx,
+		op:    op,
 		conn:  c,
 		block: block,
 		release: func(err error) {
 			release(c, err)
 		},
+		queryID:   options.queryID,
+		result:    result,
 		onProcess: onProcess,
 	}, nil
 }
@@ -68,13 +95,20 @@ func (c *connect) prepareBatch(ctx context.Context, query string, release func(*
 type batch struct {
 	err       error
 	ctx       context.Context
+	op        *operation
 	conn      *connect
 	sent      bool
 	block     *proto.Block
 	release   func(error)
+	queryID   string
+	result    *execResult
 	onProcess *onProcess
 }
 
+func (b *batch) QueryID() string {
+	return b.queryID
+}
+
 func (b *batch) Abort() error {
 	defer func() {
 		b.sent = true
@@ -105,6 +139,17 @@ func (b *batch) AppendStruct(v interface{}) error {
 	return b.Append(values...)
 }
 
+func (b *batch) AppendBlock(fn func(block *proto.Block) error) error {
+	if b.sent {
+		return ErrBatchAlreadySent
+	}
+	if err := fn(b.block); err != nil {
+		b.release(err)
+		return err
+	}
+	return nil
+}
+
 func (b *batch) Column(idx int) driver.BatchColumn {
 	if len(b.block.Columns) <= idx {
 		b.release(nil)
@@ -125,32 +170,38 @@ func (b *batch) Column(idx int) driver.BatchColumn {
 	}
 }
 
-func (b *batch) Send() (err error) {
+func (b *batch) Send() error {
+	_, err := b.SendWithResult()
+	return err
+}
+
+func (b *batch) SendWithResult() (_ *ExecResult, err error) {
 	defer func() {
 		b.sent = true
 		b.release(err)
 	}()
 	if b.sent {
-		return ErrBatchAlreadySent
+		return nil, ErrBatchAlreadySent
 	}
 	if b.err != nil {
-		return b.err
+		return nil, b.err
 	}
 	if b.block.Rows() != 0 {
+		b.op.setRows(b.block.Rows())
 		if err = b.conn.sendData(b.block, ""); err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if err = b.conn.sendData(&proto.Block{}, ""); err != nil {
-		return err
+		return nil, err
 	}
 	if err = b.conn.encoder.Flush(); err != nil {
-		return err
+		return nil, err
 	}
 	if err = b.conn.process(b.ctx, b.onProcess); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return b.result.done(), nil
 }
 
 type batchColumn struct {
@@ -176,6 +227,9 @@ func (b *batchColumn) Append(v interface{}) (err error) {
 }
 
 var (
-	_ (driver.Batch)       = (*batch)(nil)
-	_ (driver.BatchColumn) = (*batchColumn)(nil)
+	_ (driver.Batch)           = (*batch)(nil)
+	_ (driver.ResultSender)    = (*batch)(nil)
+	_ (driver.BlockAppender)   = (*batch)(nil)
+	_ (driver.QueryIdentifier) = (*batch)(nil)
+	_ (driver.BatchColumn)     = (*batchColumn)(nil)
 )
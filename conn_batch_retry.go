@@ -0,0 +1,181 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// RetryPolicy configures the retryable mode of PrepareBatch (see WithBatchRetry).
+//
+// The batch encodes its block once and, when Send fails because the connection is
+// lost, redials through the pool and sends the same bytes again. Every attempt carries
+// the same deduplication token, so the server stores the block at most once even if
+// the failed attempt was committed before the connection dropped.
+type RetryPolicy struct {
+	MaxRetries   int           // default 3
+	Backoff      time.Duration // default 100ms, doubled after every failed attempt
+	MaxBackoff   time.Duration // default 10 seconds
+	DedupToken   string        // default a random UUID per batch
+	DedupSetting string        // default insert_deduplication_token
+}
+
+func (p *RetryPolicy) setDefaults() {
+	if p.MaxRetries <= 0 {
+		p.MaxRetries = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 10 * time.Second
+	}
+	if len(p.DedupToken) == 0 {
+		p.DedupToken = uuid.NewString()
+	}
+	if len(p.DedupSetting) == 0 {
+		p.DedupSetting = "insert_deduplication_token"
+	}
+}
+
+func (ch *proton) prepareRetryableBatch(ctx context.Context, policy RetryPolicy, query string) (driver.Batch, error) {
+	policy.setDefaults()
+	var (
+		options  = queryOptions(ctx)
+		settings = make(Settings, len(options.settings)+1)
+	)
+	for k, v := range options.settings {
+		settings[k] = v
+	}
+	settings[policy.DedupSetting] = policy.DedupToken
+	options.settings = settings
+	ctx = context.WithValue(ctx, _contextOptionKey, options)
+	conn, err := ch.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := conn.prepareBatch(ctx, query, ch.release)
+	if err != nil {
+		return nil, err
+	}
+	return &retryableBatch{
+		batch:  b,
+		ch:     ch,
+		query:  query,
+		policy: policy,
+	}, nil
+}
+
+type retryableBatch struct {
+	*batch
+	ch     *proton
+	query  string
+	policy RetryPolicy
+}
+
+func (b *retryableBatch) Send() error {
+	_, err := b.SendWithResult()
+	return err
+}
+
+// SendWithResult returns the result of the attempt that succeeded.
+func (b *retryableBatch) SendWithResult() (*ExecResult, error) {
+	if b.sent || b.err != nil || b.block.Rows() == 0 {
+		return b.batch.SendWithResult()
+	}
+	revision := b.conn.revision
+	data, err := encodeBlock(b.block, revision)
+	if err != nil {
+		b.sent = true
+		b.release(err)
+		return nil, err
+	}
+	current := b.batch
+	for attempt := 0; ; attempt++ {
+		if current != nil { // nil when the previous redial failed
+			switch {
+			case current.conn.revision != revision:
+				err = fmt.Errorf("server revision changed from %d to %d", revision, current.conn.revision)
+			default:
+				current.op.setRows(b.block.Rows())
+				err = current.sendEncoded(data)
+			}
+			current.sent = true
+			current.release(err)
+		}
+		switch {
+		case err == nil:
+			return current.result.done(), nil
+		case !isConnError(err):
+			return nil, err
+		case attempt == b.policy.MaxRetries:
+			return nil, &OpError{
+				Op:  "Send",
+				Err: fmt.Errorf("could not send the batch after %d retries: %w", attempt, err),
+			}
+		}
+		b.ch.opt.log(LogWarn, "batch send failed, retrying", "attempt", attempt+1, "error", err)
+		timer := time.NewTimer(exponentialBackoff(b.policy.Backoff, b.policy.MaxBackoff, attempt+1))
+		select {
+		case <-b.ctx.Done():
+			timer.Stop()
+			return nil, b.ctx.Err()
+		case <-timer.C:
+		}
+		current, err = b.redial()
+	}
+}
+
+func (b *retryableBatch) redial() (*batch, error) {
+	conn, err := b.ch.acquire(b.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return conn.prepareBatch(b.ctx, b.query, b.ch.release)
+}
+
+func (b *batch) sendEncoded(data []byte) error {
+	if err := b.conn.sendEncodedData(data); err != nil {
+		return err
+	}
+	if err := b.conn.sendData(&proto.Block{}, ""); err != nil {
+		return err
+	}
+	if err := b.conn.encoder.Flush(); err != nil {
+		return err
+	}
+	return b.conn.process(b.ctx, b.onProcess)
+}
+
+func encodeBlock(block *proto.Block, revision uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := block.Encode(binary.NewEncoder(&buf), revision); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var _ (driver.Batch) = (*retryableBatch)(nil)
@@ -0,0 +1,126 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	var a, b RetryPolicy
+	a.setDefaults()
+	b.setDefaults()
+	assert.Equal(t, 3, a.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, a.Backoff)
+	assert.Equal(t, 10*time.Second, a.MaxBackoff)
+	assert.Equal(t, "insert_deduplication_token", a.DedupSetting)
+	assert.NotEmpty(t, a.DedupToken)
+	assert.NotEqual(t, a.DedupToken, b.DedupToken)
+
+	c := RetryPolicy{MaxRetries: 1, DedupToken: "token", DedupSetting: "idempotent_id"}
+	c.setDefaults()
+	assert.Equal(t, 1, c.MaxRetries)
+	assert.Equal(t, "token", c.DedupToken)
+	assert.Equal(t, "idempotent_id", c.DedupSetting)
+}
+
+func TestEncodeBlock(t *testing.T) {
+	var block proto.Block
+	require.NoError(t, block.AddColumn("id", "uint64"))
+	require.NoError(t, block.AddColumn("name", "string"))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, block.Append(uint64(i), "name"))
+	}
+	a, err := encodeBlock(&block, proto.ClientTCPProtocolVersion)
+	require.NoError(t, err)
+	b, err := encodeBlock(&block, proto.ClientTCPProtocolVersion)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.NotEmpty(t, a)
+}
+
+func TestRetryableBatchRedial(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		conns   []net.Conn
+		tokens  []string
+		inserts [][]*proto.Block
+	)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		var header proto.Block
+		if err := header.AddColumn("id", "uint64"); err != nil {
+			return err
+		}
+		blocks, err := w.Insert(&header)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		tokens = append(tokens, q.Settings["insert_deduplication_token"])
+		inserts = append(inserts, blocks)
+		if len(inserts) == 1 {
+			// the connection drops before the insert is acknowledged
+			conns[0].Close()
+		}
+		return nil
+	}))
+	defer srv.Close()
+	conn, err := Open(&Options{
+		Addr: []string{srv.Addr()},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+			}
+			return conn, err
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := Context(context.Background(), WithBatchRetry(RetryPolicy{
+		Backoff:    time.Millisecond,
+		DedupToken: "token",
+	}))
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO example")
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	require.NoError(t, batch.Send())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, inserts, 2)
+	assert.Len(t, conns, 2)
+	assert.Equal(t, []string{"token", "token"}, tokens)
+	if assert.Len(t, inserts[1], 1) {
+		assert.Equal(t, 3, inserts[1][0].Rows())
+	}
+}
@@ -0,0 +1,40 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertQuery(t *testing.T) {
+	for query, expected := range map[string]string{
+		"INSERT INTO t":                                  "INSERT INTO t VALUES",
+		"INSERT INTO t VALUES":                           "INSERT INTO t VALUES",
+		"insert into t values (1, 'a')":                  "insert into t VALUES",
+		"INSERT INTO t (values, b) VALUES":               "INSERT INTO t (values, b) VALUES",
+		"INSERT INTO `t VALUES (` (a)":                   "INSERT INTO `t VALUES (` (a) VALUES",
+		"INSERT INTO t (a) -- VALUES (1)\n":              "INSERT INTO t (a) VALUES",
+		"INSERT INTO t /* values ( */ (a) VALUES (1);":   "INSERT INTO t /* values ( */ (a) VALUES",
+		"INSERT INTO t (a);":                             "INSERT INTO t (a) VALUES",
+		"INSERT INTO t (a) SETTINGS x='VALUES (' VALUES": "INSERT INTO t (a) SETTINGS x='VALUES (' VALUES",
+	} {
+		assert.Equal(t, expected, insertQuery(query), query)
+	}
+}
@@ -20,22 +20,93 @@ package proton
 import (
 	"context"
 	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+)
+
+type (
+	ExecResult   = driver.ExecResult
+	ResultExecer = driver.ResultExecer
+	ResultSender = driver.ResultSender
 )
 
+var _ (driver.ResultExecer) = (*proton)(nil)
+
 func (c *connect) exec(ctx context.Context, query string, args ...interface{}) error {
+	_, err := c.execWithResult(ctx, query, args...)
+	return err
+}
+
+func (c *connect) execWithResult(ctx context.Context, query string, args ...interface{}) (_ *ExecResult, err error) {
 	var (
-		options   = queryOptions(ctx)
-		body, err = bind(c.server.Timezone, query, args...)
+		options   = c.queryOptions(ctx)
+		onProcess = options.onProcess()
+		result    = newExecResult(options.queryID, onProcess)
+		body      string
 	)
+	body, err = c.bind(&options, query, args...)
+	op := c.startOperation(ctx, "exec", query, &options)
+	op.observe(onProcess)
+	defer func() {
+		op.end(err)
+	}()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if deadline, ok := ctx.Deadline(); ok {
 		c.conn.SetDeadline(deadline)
 		defer c.conn.SetDeadline(time.Time{})
 	}
 	if err := c.sendQuery(body, &options); err != nil {
-		return err
+		return nil, err
+	}
+	if err := c.process(ctx, onProcess); err != nil {
+		return nil, err
+	}
+	return result.done(), nil
+}
+
+// execResult collects the ExecResult of a query from the packets of the server.
+type execResult struct {
+	start  time.Time
+	result ExecResult
+}
+
+func newExecResult(queryID string, on *onProcess) *execResult {
+	r := execResult{
+		start: time.Now(),
+		result: ExecResult{
+			QueryID: queryID,
+		},
 	}
-	return c.process(ctx, options.onProcess())
+	var (
+		progress      = on.progress
+		profileEvents = on.profileEvents
+	)
+	on.progress = func(p *Progress) {
+		r.result.WrittenRows += p.WroteRows
+		r.result.WrittenBytes += p.WroteBytes
+		progress(p)
+	}
+	on.profileEvents = func(events []ProfileEvent) {
+		if r.result.ProfileEvents == nil && len(events) != 0 {
+			r.result.ProfileEvents = make(map[string]int64)
+		}
+		for _, e := range events {
+			switch e.Type {
+			case "gauge":
+				r.result.ProfileEvents[e.Name] = e.Value
+			default:
+				r.result.ProfileEvents[e.Name] += e.Value
+			}
+		}
+		profileEvents(events)
+	}
+	return &r
+}
+
+func (r *execResult) done() *ExecResult {
+	result := r.result
+	result.Elapsed = time.Since(r.start)
+	return &result
 }
@@ -0,0 +1,120 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func profileEvents(t *testing.T, events ...ProfileEvent) *proto.Block {
+	var block proto.Block
+	for _, c := range []struct {
+		name string
+		t    column.Type
+	}{
+		{"host_name", "string"},
+		{"current_time", "datetime"},
+		{"thread_id", "uint64"},
+		{"type", "string"},
+		{"name", "string"},
+		{"value", "int64"},
+	} {
+		require.NoError(t, block.AddColumn(c.name, c.t))
+	}
+	for _, e := range events {
+		require.NoError(t, block.Append("localhost", time.Now(), uint64(1), e.Type, e.Name, e.Value))
+	}
+	return &block
+}
+
+func newExecServer(t *testing.T) *protontest.Server {
+	return protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		if !strings.Contains(q.Body, "SELECT") {
+			var header proto.Block
+			header.AddColumn("n", "uint64")
+			if _, err := w.Insert(&header); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < 2; i++ {
+			if err := w.Progress(proto.Progress{WroteRows: 5, WroteBytes: 40}); err != nil {
+				return err
+			}
+			if err := w.ProfileEvents(profileEvents(t,
+				ProfileEvent{Type: "increment", Name: "InsertedRows", Value: 5},
+				ProfileEvent{Type: "gauge", Name: "MemoryTrackerUsage", Value: int64(100 * (i + 1))},
+			)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func TestExecWithResult(t *testing.T) {
+	srv := newExecServer(t)
+	defer srv.Close()
+	conn, err := Open(&Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := Context(context.Background(), WithQueryID("exec-1"))
+	result, err := conn.(ResultExecer).ExecWithResult(ctx, "INSERT INTO example SELECT number FROM numbers(10)")
+	require.NoError(t, err)
+	assert.Equal(t, "exec-1", result.QueryID)
+	assert.Equal(t, uint64(10), result.WrittenRows)
+	assert.Equal(t, uint64(80), result.WrittenBytes)
+	assert.NotZero(t, result.Elapsed)
+	assert.Equal(t, map[string]int64{
+		"InsertedRows":       10,
+		"MemoryTrackerUsage": 200,
+	}, result.ProfileEvents)
+
+	batch, err := conn.PrepareBatch(context.Background(), "INSERT INTO example")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	result, err = batch.(ResultSender).SendWithResult()
+	require.NoError(t, err)
+	assert.Equal(t, batch.(QueryIdentifier).QueryID(), result.QueryID)
+	assert.Equal(t, uint64(10), result.WrittenRows)
+	_, err = batch.(ResultSender).SendWithResult()
+	assert.ErrorIs(t, err, ErrBatchAlreadySent)
+}
+
+func TestExecRowsAffected(t *testing.T) {
+	srv := newExecServer(t)
+	defer srv.Close()
+	db := OpenDB(&Options{Addr: []string{srv.Addr()}})
+	defer db.Close()
+	result, err := db.Exec("INSERT INTO example SELECT number FROM numbers(10)")
+	require.NoError(t, err)
+	rows, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), rows)
+}
@@ -25,12 +25,13 @@ import (
 )
 
 func (c *connect) handshake(database, username, password string) error {
-	c.debugf("[handshake] -> %s", proto.ClientHandshake{})
+	hello := proto.ClientHandshake{ProtocolVersion: c.revision}
+	c.debug("send hello", "client", hello)
 	c.conn.SetDeadline(time.Now().Add(c.opt.DialTimeout))
 	defer c.conn.SetDeadline(time.Time{})
 	{
-		c.encoder.Byte(proto.ClientHello)
-		if err := (&proto.ClientHandshake{}).Encode(c.encoder); err != nil {
+		c.writePacket(proto.ClientHello)
+		if err := hello.Encode(c.encoder); err != nil {
 			return err
 		}
 		{
@@ -40,7 +41,11 @@ func (c *connect) handshake(database, username, password string) error {
 			if err := c.encoder.String(username); err != nil {
 				return err
 			}
-			if err := c.encoder.String(password); err != nil {
+			// the capture masks the password, see io.Stream.WriteSecret
+			if err := c.encoder.Uvarint(uint64(len(password))); err != nil {
+				return err
+			}
+			if _, err := c.stream.WriteSecret([]byte(password)); err != nil {
 				return err
 			}
 		}
@@ -49,7 +54,7 @@ func (c *connect) handshake(database, username, password string) error {
 		}
 	}
 	{
-		packet, err := c.decoder.ReadByte()
+		packet, err := c.readPacket()
 		if err != nil {
 			return err
 		}
@@ -61,7 +66,7 @@ func (c *connect) handshake(database, username, password string) error {
 				return err
 			}
 		case proto.ServerEndOfStream:
-			c.debugf("[handshake] <- end of stream")
+			c.debug("handshake end of stream", "packet", "end_of_stream")
 			return nil
 		default:
 			return fmt.Errorf("[handshake] unexpected packet [%d] from server", packet)
@@ -72,8 +77,16 @@ func (c *connect) handshake(database, username, password string) error {
 	}
 	if c.revision > c.server.Revision {
 		c.revision = c.server.Revision
-		c.debugf("[handshake] downgrade client proto")
+		c.debug("downgrade client protocol", "client_revision", hello.ProtocolVersion, "server_revision", c.server.Revision)
+	}
+	c.debug("read hello", "packet", "hello", "server", c.server)
+	if c.revision >= proto.DBMS_MIN_PROTOCOL_VERSION_WITH_ADDENDUM {
+		if err := c.encoder.String("" /* quota key */); err != nil {
+			return err
+		}
+		if err := c.encoder.Flush(); err != nil {
+			return err
+		}
 	}
-	c.debugf("[handshake] <- %s", c.server)
 	return nil
 }
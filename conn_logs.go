@@ -39,7 +39,7 @@ func (c *connect) logs() ([]Log, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.debugf("[logs] rows=%d", block.Rows())
+	c.debug("logs", "packet", "log", "rows", block.Rows())
 	var (
 		logs  []Log
 		names = block.ColumnsNames()
@@ -28,12 +28,22 @@ import (
 // Connection::ping
 // https://github.com/ClickHouse/ClickHouse/blob/master/src/Client/Connection.cpp
 func (c *connect) ping(ctx context.Context) (err error) {
+	op := c.startOperation(ctx, "ping", "", nil)
+	defer func() {
+		op.end(err)
+	}()
+	return c.sendPing(ctx)
+}
+
+// sendPing sends a ping and waits for the pong, ping also records it as an operation.
+func (c *connect) sendPing(ctx context.Context) (err error) {
 	if deadline, ok := ctx.Deadline(); ok {
 		c.conn.SetDeadline(deadline)
 		defer c.conn.SetDeadline(time.Time{})
 	}
-	c.debugf("[ping] -> ping")
-	if err := c.encoder.Byte(proto.ClientPing); err != nil {
+	c.queryID = ""
+	c.debug("send ping")
+	if err := c.writePacket(proto.ClientPing); err != nil {
 		return err
 	}
 	if err := c.encoder.Flush(); err != nil {
@@ -41,7 +51,7 @@ func (c *connect) ping(ctx context.Context) (err error) {
 	}
 	var packet byte
 	for {
-		if packet, err = c.decoder.ReadByte(); err != nil {
+		if packet, err = c.readPacket(); err != nil {
 			return err
 		}
 		switch packet {
@@ -50,7 +60,7 @@ func (c *connect) ping(ctx context.Context) (err error) {
 				return err
 			}
 		case proto.ServerPong:
-			c.debugf("[ping] <- pong")
+			c.debug("read pong", "packet", "pong")
 			return nil
 		default:
 			return fmt.Errorf("unexpected packet %d", packet)
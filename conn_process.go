@@ -42,15 +42,19 @@ func (c *connect) firstBlock(ctx context.Context, on *onProcess) (*proto.Block,
 			return nil, ctx.Err()
 		default:
 		}
-		packet, err := c.decoder.ReadByte()
+		packet, err := c.readPacket()
 		if err != nil {
+			if ctx.Err() != nil {
+				c.cancel()
+			}
 			return nil, err
 		}
 		switch packet {
 		case proto.ServerData:
 			return c.readData(packet, true)
 		case proto.ServerEndOfStream:
-			c.debugf("[end of stream]")
+			c.debug("end of stream", "packet", "end_of_stream")
+			c.acknowledge()
 			return nil, io.EOF
 		default:
 			if err := c.handle(packet, on); err != nil {
@@ -68,13 +72,18 @@ func (c *connect) process(ctx context.Context, on *onProcess) error {
 			return ctx.Err()
 		default:
 		}
-		packet, err := c.decoder.ReadByte()
+		packet, err := c.readPacket()
 		if err != nil {
+			// the deadline of ctx interrupted the read, the server may not know the query is over
+			if ctx.Err() != nil {
+				c.cancel()
+			}
 			return err
 		}
 		switch packet {
 		case proto.ServerEndOfStream:
-			c.debugf("[end of stream]")
+			c.debug("end of stream", "packet", "end_of_stream")
+			c.acknowledge()
 			return nil
 		}
 		if err := c.handle(packet, on); err != nil {
@@ -94,20 +103,21 @@ func (c *connect) handle(packet byte, on *onProcess) error {
 			on.data(block)
 		}
 	case proto.ServerException:
+		c.acknowledge()
 		return c.exception()
 	case proto.ServerProfileInfo:
 		var info proto.ProfileInfo
 		if err := info.Decode(c.decoder, c.revision); err != nil {
 			return err
 		}
-		c.debugf("[profile info] %s", &info)
+		c.debug("profile info", "packet", "profile_info", "rows", info.Rows, "bytes", info.Bytes, "blocks", info.Blocks)
 		on.profileInfo(&info)
 	case proto.ServerTableColumns:
 		var info proto.TableColumns
 		if err := info.Decode(c.decoder, c.revision); err != nil {
 			return err
 		}
-		c.debugf("[table columns]")
+		c.debug("table columns", "packet", "table_columns")
 	case proto.ServerProfileEvents:
 		events, err := c.profileEvents()
 		if err != nil {
@@ -125,7 +135,6 @@ func (c *connect) handle(packet byte, on *onProcess) error {
 		if err != nil {
 			return err
 		}
-		c.debugf("[progress] %s", progress)
 		on.progress(progress)
 	default:
 		return &OpError{
@@ -136,12 +145,94 @@ func (c *connect) handle(packet byte, on *onProcess) error {
 	return nil
 }
 
+// cancel sends ClientCancel. The server has CancelGracePeriod to end the query with an end of
+// stream or an exception, see acknowledge, before the connection deadline is hit and the query is
+// killed from another connection. The connection is not reused, it is drained once released.
 func (c *connect) cancel() error {
-	c.conn.SetDeadline(time.Now().Add(2 * time.Second))
-	c.debugf("[cancel]")
-	c.closed = true
-	if err := c.encoder.Uvarint(proto.ClientCancel); err == nil {
+	grace := c.opt.CancelGracePeriod
+	c.conn.SetDeadline(time.Now().Add(grace))
+	c.debug("send cancel", "grace_period", grace)
+	c.cancelMu.Lock()
+	c.canceled = true
+	if c.killer == nil && c.kill != nil && len(c.queryID) != 0 {
+		queryID := c.queryID
+		c.killer = time.AfterFunc(grace, func() {
+			c.killCanceled(queryID)
+		})
+	}
+	c.cancelMu.Unlock()
+	if err := c.writePacket(proto.ClientCancel); err != nil {
 		return err
 	}
 	return c.encoder.Flush()
 }
+
+// acknowledge stops the KILL QUERY fallback of cancel, the server ended the query.
+func (c *connect) acknowledge() {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	c.acknowledged = true
+	if c.killer != nil {
+		c.killer.Stop()
+	}
+}
+
+// pending reports whether the server has not acknowledged the cancel of the query yet.
+func (c *connect) pending() bool {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+	return c.canceled && !c.acknowledged
+}
+
+func (c *connect) killCanceled(queryID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opt.DialTimeout+c.opt.CancelGracePeriod)
+	defer cancel()
+	if err := c.kill(ctx, queryID); err != nil {
+		c.opt.log(LogWarn, "kill query failed", "query_id", queryID, "error", err)
+		return
+	}
+	c.opt.log(LogWarn, "query not canceled in time, killed", "query_id", queryID, "grace_period", c.opt.CancelGracePeriod)
+}
+
+func (c *connect) killQuery(ctx context.Context, queryID string) error {
+	return c.exec(ctx, "KILL QUERY WHERE query_id = "+format(time.UTC, queryID))
+}
+
+// drain discards the packets of a canceled query until the server ends it.
+func (c *connect) drain() {
+	discard := &onProcess{
+		logs:          func([]Log) {},
+		progress:      func(*Progress) {},
+		profileInfo:   func(*ProfileInfo) {},
+		profileEvents: func([]ProfileEvent) {},
+	}
+	for {
+		packet, err := c.readPacket()
+		if err != nil {
+			return
+		}
+		if packet == proto.ServerEndOfStream {
+			c.acknowledge()
+			return
+		}
+		if err := c.handle(packet, discard); err != nil {
+			return
+		}
+	}
+}
+
+func packetName(packet byte) string {
+	switch packet {
+	case proto.ServerData:
+		return "data"
+	case proto.ServerTotals:
+		return "totals"
+	case proto.ServerExtremes:
+		return "extremes"
+	case proto.ServerLog:
+		return "log"
+	case proto.ServerProfileEvents:
+		return "profile_events"
+	}
+	return fmt.Sprintf("%d", packet)
+}
@@ -0,0 +1,162 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+type testKiller struct {
+	mu     sync.Mutex
+	killed []string
+}
+
+func (k *testKiller) kill(ctx context.Context, queryID string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.killed = append(k.killed, queryID)
+	return nil
+}
+
+func (k *testKiller) queries() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string(nil), k.killed...)
+}
+
+// newCanceledConn returns a connection running the query q1, the server reads the cancel
+// packet and answers with end of stream if ack is true.
+func newCanceledConn(t *testing.T, killer *testKiller, ack bool) *connect {
+	opt := &Options{CancelGracePeriod: 50 * time.Millisecond}
+	opt.setDefaults()
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+	stream := io.NewStream(client)
+	go func() {
+		packet := make([]byte, 1)
+		if _, err := server.Read(packet); err != nil || packet[0] != proto.ClientCancel {
+			return
+		}
+		if ack {
+			server.Write([]byte{proto.ServerEndOfStream})
+		}
+	}()
+	return &connect{
+		opt:     opt,
+		conn:    client,
+		queryID: "q1",
+		stream:  stream,
+		encoder: binary.NewEncoder(stream),
+		decoder: binary.NewDecoder(stream),
+		kill:    killer.kill,
+	}
+}
+
+func TestCancelKillsIgnoredQuery(t *testing.T) {
+	var (
+		killer      testKiller
+		c           = newCanceledConn(t, &killer, false)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer c.close()
+	cancel()
+	start := time.Now()
+	assert.Equal(t, context.Canceled, c.process(ctx, &onProcess{}))
+	assert.Less(t, time.Since(start), c.opt.CancelGracePeriod, "process must not wait for the grace period")
+	assert.True(t, c.pending())
+	assert.True(t, c.isBad())
+	require.Eventually(t, func() bool {
+		return len(killer.queries()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"q1"}, killer.queries())
+}
+
+func TestCancelAcknowledged(t *testing.T) {
+	var (
+		killer      testKiller
+		c           = newCanceledConn(t, &killer, true)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer c.close()
+	cancel()
+	assert.Equal(t, context.Canceled, c.process(ctx, &onProcess{}))
+	c.drain()
+	assert.False(t, c.pending())
+	time.Sleep(2 * c.opt.CancelGracePeriod)
+	assert.Empty(t, killer.queries())
+}
+
+func TestCancelReturnsImmediately(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		killed []string
+		done   = make(chan struct{})
+	)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		if strings.HasPrefix(q.Body, "KILL QUERY") {
+			mu.Lock()
+			killed = append(killed, q.Body)
+			mu.Unlock()
+			return nil
+		}
+		for {
+			select {
+			case <-w.Canceled():
+				// the server never acknowledges the cancel
+				<-done
+				return nil
+			case <-time.After(5 * time.Millisecond):
+				if err := w.Progress(proto.Progress{Rows: 1}); err != nil {
+					return err
+				}
+			}
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+	conn, err := Open(&Options{
+		Addr:              []string{srv.Addr()},
+		CancelGracePeriod: 200 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(Context(context.Background(), WithQueryID("q1")))
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	assert.ErrorIs(t, conn.Exec(ctx, "SELECT sleep(10)"), context.Canceled)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(killed) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"KILL QUERY WHERE query_id = 'q1'"}, killed)
+}
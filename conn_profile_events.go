@@ -38,7 +38,7 @@ func (c *connect) profileEvents() ([]ProfileEvent, error) {
 	if err != nil {
 		return nil, err
 	}
-	c.debugf("[profile events] rows=%d", block.Rows())
+	c.debug("profile events", "packet", "profile_events", "rows", block.Rows())
 	var (
 		events []ProfileEvent
 		names  = block.ColumnsNames()
@@ -26,10 +26,13 @@ import (
 
 func (c *connect) query(ctx context.Context, release func(*connect, error), query string, args ...interface{}) (*rows, error) {
 	var (
-		options   = queryOptions(ctx)
+		options   = c.queryOptions(ctx)
 		onProcess = options.onProcess()
-		body, err = bind(c.server.Timezone, query, args...)
+		body, err = c.bind(&options, query, args...)
+		op        = c.startOperation(ctx, "query", query, &options)
 	)
+	op.observe(onProcess)
+	release = op.release(release)
 
 	if err != nil {
 		release(c, err)
@@ -76,6 +79,7 @@ func (c *connect) query(ctx context.Context, release func(*connect, error), quer
 		stream:    stream,
 		errors:    errors,
 		columns:   init.ColumnsNames(),
+		queryID:   options.queryID,
 		structMap: c.structMap,
 	}, nil
 }
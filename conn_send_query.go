@@ -24,8 +24,9 @@ import (
 // Connection::sendQuery
 // https://github.com/ClickHouse/ClickHouse/blob/master/src/Client/Connection.cpp
 func (c *connect) sendQuery(body string, o *QueryOptions) error {
-	c.debugf("[send query] compression=%t %s", c.compression, body)
-	if err := c.encoder.Byte(proto.ClientQuery); err != nil {
+	c.queryID = o.queryID
+	c.debug("send query", "compression", c.compression, "query", body)
+	if err := c.writePacket(proto.ClientQuery); err != nil {
 		return err
 	}
 	q := proto.Query{
@@ -36,6 +37,7 @@ func (c *connect) sendQuery(body string, o *QueryOptions) error {
 		Compression:    c.compression,
 		InitialAddress: c.conn.LocalAddr().String(),
 		Settings:       c.settings(o.settings),
+		Parameters:     o.parameters,
 	}
 	if err := q.Encode(c.encoder, c.revision); err != nil {
 		return err
@@ -0,0 +1,232 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"sync"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+func (c *connect) subscribe(ctx context.Context, release func(*connect, error), query string, args ...interface{}) (*subscription, error) {
+	var (
+		options   = c.queryOptions(ctx)
+		onProcess = options.onProcess()
+		body, err = c.bind(&options, query, args...)
+		op        = c.startOperation(ctx, "stream", query, &options)
+	)
+	op.observe(onProcess)
+	release = op.release(release)
+	if err != nil {
+		release(c, err)
+		return nil, err
+	}
+	if err = c.sendQuery(body, &options); err != nil {
+		release(c, err)
+		return nil, err
+	}
+	header, err := c.firstBlock(ctx, onProcess)
+	if err != nil {
+		release(c, err)
+		return nil, err
+	}
+	s := &subscription{
+		header:    header,
+		blocks:    make(chan *proto.Block, 2),
+		done:      make(chan struct{}),
+		finished:  make(chan struct{}),
+		queryID:   options.queryID,
+		structMap: c.structMap,
+	}
+	{
+		progress := onProcess.progress
+		onProcess.progress = func(p *Progress) {
+			s.addProgress(p)
+			progress(p)
+		}
+		onProcess.data = func(b *proto.Block) {
+			select {
+			case s.blocks <- b:
+			case <-s.done:
+			case <-ctx.Done():
+			}
+		}
+	}
+	go s.run(ctx, c, onProcess, release)
+	return s, nil
+}
+
+type subscription struct {
+	err       error
+	mutex     sync.Mutex
+	header    *proto.Block
+	blocks    chan *proto.Block
+	rows      chan driver.StreamRow
+	rowsOnce  sync.Once
+	progress  proto.Progress
+	done      chan struct{}
+	doneOnce  sync.Once
+	finished  chan struct{}
+	queryID   string
+	structMap structMap
+}
+
+func (s *subscription) QueryID() string {
+	return s.queryID
+}
+
+func (s *subscription) run(ctx context.Context, c *connect, on *onProcess, release func(*connect, error)) {
+	defer close(s.finished)
+	var (
+		processed = make(chan struct{})
+		watched   = make(chan struct{})
+		cancelled bool
+	)
+	go func() {
+		defer close(watched)
+		select {
+		case <-ctx.Done():
+		case <-s.done:
+		case <-processed:
+			return
+		}
+		// the reader goroutine is blocked on the socket, so the cancel packet is
+		// sent from here and the server is expected to answer with end of stream.
+		cancelled = true
+		c.cancel()
+	}()
+	// cancellation is driven by the watcher above, process must not cancel on its own.
+	err := c.process(context.Background(), on)
+	close(processed)
+	<-watched
+	switch {
+	case cancelled && ctx.Err() != nil:
+		s.setErr(ctx.Err())
+	case cancelled:
+	case err != nil:
+		s.setErr(err)
+	}
+	if cancelled && err == nil {
+		err = context.Canceled
+	}
+	close(s.blocks)
+	release(c, err)
+}
+
+func (s *subscription) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func (s *subscription) addProgress(p *Progress) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.progress.Rows += p.Rows
+	s.progress.Bytes += p.Bytes
+	s.progress.TotalRows += p.TotalRows
+	s.progress.WroteRows += p.WroteRows
+	s.progress.WroteBytes += p.WroteBytes
+}
+
+func (s *subscription) Columns() []string {
+	return s.header.ColumnsNames()
+}
+
+func (s *subscription) ColumnTypes() []driver.ColumnType {
+	return columnTypes(s.header)
+}
+
+func (s *subscription) Blocks() <-chan *proto.Block {
+	return s.blocks
+}
+
+func (s *subscription) Rows() <-chan driver.StreamRow {
+	s.rowsOnce.Do(func() {
+		s.rows = streamRows(s.blocks, s.done, s.structMap)
+	})
+	return s.rows
+}
+
+func (s *subscription) Progress() proto.Progress {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.progress
+}
+
+func (s *subscription) Err() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// Close cancels the query and waits for the connection to be released.
+// It does not drain the stream: the server has CancelGracePeriod (see connect.cancel)
+// to acknowledge the cancel before the connection is dropped.
+func (s *subscription) Close() error {
+	s.doneOnce.Do(func() {
+		close(s.done)
+	})
+	<-s.finished
+	return s.Err()
+}
+
+func streamRows(blocks <-chan *proto.Block, done <-chan struct{}, structMap structMap) chan driver.StreamRow {
+	rows := make(chan driver.StreamRow)
+	go func() {
+		defer close(rows)
+		for block := range blocks {
+			for i := 1; i <= block.Rows(); i++ {
+				select {
+				case rows <- &streamRow{block: block, row: i, structMap: structMap}:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+	return rows
+}
+
+type streamRow struct {
+	row       int
+	block     *proto.Block
+	structMap structMap
+}
+
+func (r *streamRow) Scan(dest ...interface{}) error {
+	return scan(r.block, r.row, dest...)
+}
+
+func (r *streamRow) ScanStruct(dest interface{}) error {
+	values, err := r.structMap.Map("ScanStruct", r.block.ColumnsNames(), dest, true)
+	if err != nil {
+		return err
+	}
+	return r.Scan(values...)
+}
+
+type Streamer = driver.Streamer
+
+var (
+	_ (driver.Streamer)  = (*proton)(nil)
+	_ (driver.Stream)    = (*subscription)(nil)
+	_ (driver.StreamRow) = (*streamRow)(nil)
+)
@@ -48,8 +48,11 @@ type (
 			profileInfo   func(*ProfileInfo)
 			profileEvents func([]ProfileEvent)
 		}
-		settings Settings
-		external []*external.Table
+		settings   Settings
+		parameters map[string]string
+		external   []*external.Table
+		resume     *ResumePolicy
+		retry      *RetryPolicy
 	}
 )
 
@@ -123,6 +126,24 @@ func WithStdAsync(wait bool) QueryOption {
 	}
 }
 
+// WithStreamResume makes Conn.Stream transparently redial and resume the query
+// from the last seen value of policy.Cursor when the connection is lost.
+func WithStreamResume(policy ResumePolicy) QueryOption {
+	return func(o *QueryOptions) error {
+		o.resume = &policy
+		return nil
+	}
+}
+
+// WithBatchRetry makes the batches of PrepareBatch resend their block on a new
+// connection when Send fails because the connection is lost.
+func WithBatchRetry(policy RetryPolicy) QueryOption {
+	return func(o *QueryOptions) error {
+		o.retry = &policy
+		return nil
+	}
+}
+
 func Context(parent context.Context, options ...QueryOption) context.Context {
 	opt := QueryOptions{
 		settings: make(Settings),
@@ -147,6 +168,16 @@ func queryOptions(ctx context.Context) QueryOptions {
 	}
 }
 
+// queryOptions are the options of ctx with a query ID from Options.QueryIDGenerator
+// when the caller did not set one.
+func (c *connect) queryOptions(ctx context.Context) QueryOptions {
+	options := queryOptions(ctx)
+	if len(options.queryID) == 0 {
+		options.queryID = c.opt.QueryIDGenerator(ctx)
+	}
+	return options
+}
+
 func (q *QueryOptions) onProcess() *onProcess {
 	return &onProcess{
 		logs: func(logs []Log) {
@@ -0,0 +1,53 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proton
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestQueryIDGenerator(t *testing.T) {
+	opt := &Options{}
+	opt.setDefaults()
+	c := &connect{opt: opt}
+	var (
+		first  = c.queryOptions(context.Background())
+		second = c.queryOptions(context.Background())
+	)
+	_, err := uuid.Parse(first.QueryID())
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.QueryID(), second.QueryID())
+	options := c.queryOptions(Context(context.Background(), WithQueryID("mine")))
+	assert.Equal(t, "mine", options.QueryID())
+
+	// derive the query ID from the trace ID of the caller
+	opt.QueryIDGenerator = func(ctx context.Context) string {
+		return trace.SpanContextFromContext(ctx).TraceID().String() + "-1"
+	}
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{1},
+	})
+	options = c.queryOptions(trace.ContextWithSpanContext(context.Background(), span))
+	assert.Equal(t, "01020300000000000000000000000000-1", options.QueryID())
+}
@@ -39,7 +39,7 @@ func example() error {
 		},
 		DialTimeout: 5 * time.Second,
 		Compression: &proton.Compression{
-			proton.CompressionLZ4,
+			Method: proton.CompressionLZ4,
 		},
 		//Debug: true,
 	})
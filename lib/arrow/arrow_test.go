@@ -0,0 +1,163 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var block proto.Block
+	for _, c := range []struct {
+		name string
+		t    column.Type
+	}{
+		{"col1", "int64"},
+		{"col2", "nullable(string)"},
+		{"col3", "low_cardinality(string)"},
+		{"col4", "array(nullable(int32))"},
+		{"col5", "map(string, uint64)"},
+		{"col6", "tuple(a string, b int8)"},
+		{"col7", "decimal(18, 4)"},
+		{"col8", "datetime64(3, 'UTC')"},
+		{"col9", "uuid"},
+		{"col10", "ipv4"},
+		{"col11", "ipv6"},
+		{"col12", "array(array(string))"},
+		{"col13", "bool"},
+		{"col14", "nullable(float64)"},
+	} {
+		require.NoError(t, block.AddColumn(c.name, c.t))
+	}
+	var (
+		now  = time.Now().UTC().Truncate(time.Millisecond)
+		id   = uuid.New()
+		str  = "str"
+		i32  = int32(42)
+		f64  = 4.2
+		rows = [][]interface{}{
+			{
+				int64(1), &str, "lc", []*int32{&i32, nil}, map[string]uint64{"a": 1, "b": 2},
+				[]interface{}{"x", int8(1)}, decimal.RequireFromString("12.3456"), now, id,
+				net.ParseIP("127.0.0.1").To4(), net.ParseIP("::1"), [][]string{{"a"}, {}, {"b", "c"}},
+				true, &f64,
+			},
+			{
+				int64(2), nil, "lc", []*int32{}, map[string]uint64{},
+				[]interface{}{"y", int8(-1)}, decimal.RequireFromString("-1"), now.Add(time.Second), uuid.Nil,
+				net.ParseIP("10.0.0.1").To4(), net.ParseIP("2001:db8::1"), [][]string{},
+				false, nil,
+			},
+		}
+	)
+	for _, row := range rows {
+		require.NoError(t, block.Append(row...))
+	}
+
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	record, err := Record(&block, mem)
+	require.NoError(t, err)
+	defer record.Release()
+
+	assert.Equal(t, int64(2), record.NumRows())
+	assert.Equal(t, int64(14), record.NumCols())
+	assert.Equal(t, []int64{1, 2}, record.Column(0).(*array.Int64).Int64Values())
+	assert.True(t, record.Column(1).IsNull(1))
+	assert.Equal(t, "str", record.Column(1).(*array.String).Value(0))
+	assert.Equal(t, arrow.Timestamp(now.UnixMilli()), record.Column(7).(*array.Timestamp).Value(0))
+
+	result, err := Block(record)
+	require.NoError(t, err)
+	require.Equal(t, block.ColumnsNames(), result.ColumnsNames())
+	require.Equal(t, block.Rows(), result.Rows())
+	for i, c := range block.Columns {
+		assert.Equal(t, c.Type(), result.Columns[i].Type())
+		for row := 0; row < block.Rows(); row++ {
+			expected, actual := c.Row(row, false), result.Columns[i].Row(row, false)
+			if d, ok := expected.(decimal.Decimal); ok {
+				assert.True(t, d.Equal(actual.(decimal.Decimal)), "column %s row %d", c.Type(), row)
+				continue
+			}
+			assert.Equal(t, expected, actual, "column %s row %d", c.Type(), row)
+		}
+	}
+}
+
+func TestBlockWithoutMetadata(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "tags", Type: arrow.ListOfNonNullable(arrow.BinaryTypes.String)},
+	}, nil)
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.Int32Builder).AppendValues([]int32{1, 2}, nil)
+	builder.Field(1).(*array.StringBuilder).AppendValues([]string{"a", ""}, []bool{true, false})
+	tags := builder.Field(2).(*array.ListBuilder)
+	tags.Append(true)
+	tags.ValueBuilder().(*array.StringBuilder).AppendValues([]string{"x", "y"}, nil)
+	tags.Append(true)
+	record := builder.NewRecord()
+	defer record.Release()
+
+	block, err := Block(record)
+	require.NoError(t, err)
+	assert.Equal(t, column.Type("int32"), block.Columns[0].Type())
+	assert.Equal(t, column.Type("nullable(string)"), block.Columns[1].Type())
+	assert.Equal(t, column.Type("array(string)"), block.Columns[2].Type())
+	assert.Equal(t, []int32{1, 2}, []int32(*block.Columns[0].(*column.Int32)))
+	var (
+		name *string
+		list []string
+	)
+	require.NoError(t, block.Columns[1].ScanRow(&name, 1))
+	assert.Nil(t, name)
+	require.NoError(t, block.Columns[2].ScanRow(&list, 0))
+	assert.Equal(t, []string{"x", "y"}, list)
+}
+
+func TestAppendConvertsWidth(t *testing.T) {
+	var block proto.Block
+	require.NoError(t, block.AddColumn("id", "int64"))
+	schema := arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Int32}}, nil)
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	builder.Field(0).(*array.Int32Builder).AppendValues([]int32{7, 8}, nil)
+	record := builder.NewRecord()
+	defer record.Release()
+	require.NoError(t, Append(&block, record))
+	assert.Equal(t, []int64{7, 8}, []int64(*block.Columns[0].(*column.Int64)))
+
+	require.NoError(t, block.AddColumn("missing", "string"))
+	assert.Error(t, Append(&block, record))
+}
@@ -0,0 +1,313 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// Block converts an Arrow record into a new block. Column types are taken from the
+// MetadataType field metadata when present and derived from the Arrow types otherwise.
+func Block(record arrow.Record) (*proto.Block, error) {
+	var block proto.Block
+	for _, field := range record.Schema().Fields() {
+		var t string
+		if idx := field.Metadata.FindKey(MetadataType); idx >= 0 {
+			t = field.Metadata.Values()[idx]
+		} else {
+			ct, err := columnType(field.Type, field.Nullable)
+			if err != nil {
+				return nil, err
+			}
+			t = string(ct)
+		}
+		if err := block.AddColumn(field.Name, column.Type(t)); err != nil {
+			return nil, err
+		}
+	}
+	if err := Append(&block, record); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// Append appends the rows of an Arrow record to a block, record columns are matched by name.
+func Append(block *proto.Block, record arrow.Record) error {
+	arrays := make([]arrow.Array, 0, len(block.Columns))
+	for _, name := range block.ColumnsNames() {
+		idx := record.Schema().FieldIndices(name)
+		if len(idx) == 0 {
+			return &proto.BlockError{
+				Op:         "AppendArrow",
+				Err:        fmt.Errorf("the record has no column %q", name),
+				ColumnName: name,
+			}
+		}
+		arrays = append(arrays, record.Column(idx[0]))
+	}
+	for i, c := range block.Columns {
+		if err := appendArray(c, arrays[i]); err != nil {
+			return &proto.BlockError{
+				Op:         "AppendArrow",
+				Err:        err,
+				ColumnName: block.ColumnsNames()[i],
+			}
+		}
+	}
+	return nil
+}
+
+func appendArray(col column.Interface, arr arrow.Array) error {
+	// fast path: fixed width arrays without nulls are appended as a whole
+	if arr.NullN() == 0 {
+		var values interface{}
+		switch arr := arr.(type) {
+		case *array.Int8:
+			values = arr.Int8Values()
+		case *array.Int16:
+			values = arr.Int16Values()
+		case *array.Int32:
+			values = arr.Int32Values()
+		case *array.Int64:
+			values = arr.Int64Values()
+		case *array.Uint8:
+			values = arr.Uint8Values()
+		case *array.Uint16:
+			values = arr.Uint16Values()
+		case *array.Uint32:
+			values = arr.Uint32Values()
+		case *array.Uint64:
+			values = arr.Uint64Values()
+		case *array.Float32:
+			values = arr.Float32Values()
+		case *array.Float64:
+			values = arr.Float64Values()
+		}
+		if values != nil && reflect.TypeOf(values).Elem() == col.ScanType() {
+			_, err := col.Append(values)
+			return err
+		}
+	}
+	for i := 0; i < arr.Len(); i++ {
+		v, err := value(arr, i, col)
+		if err != nil {
+			return err
+		}
+		if err := col.AppendRow(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// value returns the i-th value of arr in the form expected by col.AppendRow.
+func value(arr arrow.Array, i int, col column.Interface) (interface{}, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+	switch col := col.(type) {
+	case *column.Nullable:
+		return value(arr, i, col.Base())
+	case *column.LowCardinality:
+		return value(arr, i, col.Base())
+	case *column.Array:
+		v, err := listValue(arr, i, col.Depth(), col.Base(), col.ScanType())
+		if err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	case *column.Map:
+		m, ok := arr.(*array.Map)
+		if !ok {
+			return nil, fmt.Errorf("converting %s to %s is unsupported", arr.DataType(), col.Type())
+		}
+		var (
+			offsets    = m.Offsets()
+			keys       = m.Keys()
+			items      = m.Items()
+			scanType   = col.ScanType()
+			start, end = int(offsets[i]), int(offsets[i+1])
+			out        = reflect.MakeMapWithSize(scanType, end-start)
+		)
+		for j := start; j < end; j++ {
+			key, err := typedValue(keys, j, col.Key(), scanType.Key())
+			if err != nil {
+				return nil, err
+			}
+			item, err := typedValue(items, j, col.Value(), scanType.Elem())
+			if err != nil {
+				return nil, err
+			}
+			out.SetMapIndex(key, item)
+		}
+		return out.Interface(), nil
+	case *column.Tuple:
+		s, ok := arr.(*array.Struct)
+		if !ok || s.NumField() != len(col.Columns()) {
+			return nil, fmt.Errorf("converting %s to %s is unsupported", arr.DataType(), col.Type())
+		}
+		tuple := make([]interface{}, 0, s.NumField())
+		for j, c := range col.Columns() {
+			v, err := value(s.Field(j), i, c)
+			if err != nil {
+				return nil, err
+			}
+			tuple = append(tuple, v)
+		}
+		return tuple, nil
+	}
+	var v interface{}
+	switch arr := arr.(type) {
+	case *array.Int8:
+		v = arr.Value(i)
+	case *array.Int16:
+		v = arr.Value(i)
+	case *array.Int32:
+		v = arr.Value(i)
+	case *array.Int64:
+		v = arr.Value(i)
+	case *array.Uint8:
+		v = arr.Value(i)
+	case *array.Uint16:
+		v = arr.Value(i)
+	case *array.Uint32:
+		v = arr.Value(i)
+	case *array.Uint64:
+		v = arr.Value(i)
+	case *array.Float32:
+		v = arr.Value(i)
+	case *array.Float64:
+		v = arr.Value(i)
+	case *array.String:
+		v = arr.Value(i)
+	case *array.Binary:
+		v = string(arr.Value(i))
+	case *array.Boolean:
+		v = arr.Value(i)
+	case *array.Date32:
+		v = time.Unix(int64(arr.Value(i))*24*60*60, 0).UTC()
+	case *array.Date64:
+		v = time.UnixMilli(int64(arr.Value(i))).UTC()
+	case *array.Timestamp:
+		t := arr.DataType().(*arrow.TimestampType)
+		value := time.Unix(0, int64(arr.Value(i))*int64(t.Unit.Multiplier())).UTC()
+		if len(t.TimeZone) != 0 {
+			if loc, err := time.LoadLocation(t.TimeZone); err == nil {
+				value = value.In(loc)
+			}
+		}
+		v = value
+	case *array.Decimal128:
+		v = decimal.NewFromBigInt(arr.Value(i).BigInt(), -arr.DataType().(*arrow.Decimal128Type).Scale)
+	case *array.Decimal256:
+		v = decimal.NewFromBigInt(arr.Value(i).BigInt(), -arr.DataType().(*arrow.Decimal256Type).Scale)
+	case *array.FixedSizeBinary:
+		data := arr.Value(i)
+		switch col.(type) {
+		case *column.UUID:
+			id, err := uuid.FromBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			v = id
+		case *column.IPv4, *column.IPv6:
+			v = append(net.IP(nil), data...)
+		default:
+			v = string(data)
+		}
+	case *array.Dictionary:
+		return value(arr.Dictionary(), arr.GetValueIndex(i), col)
+	default:
+		return nil, fmt.Errorf("converting %s to %s is unsupported", arr.DataType(), col.Type())
+	}
+	// numeric values are converted to the width of the column, e.g. int32 to int64
+	if scanType, rv := col.ScanType(), reflect.ValueOf(v); scanType != nil && rv.Type() != scanType {
+		switch rv.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if rv.CanConvert(scanType) {
+				return rv.Convert(scanType).Interface(), nil
+			}
+		}
+	}
+	return v, nil
+}
+
+// typedValue is value converted to t, which is *T for nullable elements of arrays and maps.
+func typedValue(arr arrow.Array, i int, col column.Interface, t reflect.Type) (reflect.Value, error) {
+	v, err := value(arr, i, col)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v == nil {
+		return reflect.Zero(t), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Type() == t:
+		return rv, nil
+	case t.Kind() == reflect.Ptr && rv.Type() == t.Elem():
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(rv)
+		return ptr, nil
+	case rv.CanConvert(t):
+		return rv.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("converting %s to %s is unsupported", rv.Type(), t)
+}
+
+func listValue(arr arrow.Array, i, depth int, base column.Interface, t reflect.Type) (reflect.Value, error) {
+	list, ok := arr.(*array.List)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("converting %s to %s is unsupported", arr.DataType(), t)
+	}
+	var (
+		offsets    = list.Offsets()
+		values     = list.ListValues()
+		start, end = int(offsets[i]), int(offsets[i+1])
+		out        = reflect.MakeSlice(t, 0, end-start)
+	)
+	for j := start; j < end; j++ {
+		var (
+			elem reflect.Value
+			err  error
+		)
+		switch {
+		case depth > 1:
+			elem, err = listValue(values, j, depth-1, base, t.Elem())
+		default:
+			elem, err = typedValue(values, j, base, t.Elem())
+		}
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out = reflect.Append(out, elem)
+	}
+	return out, nil
+}
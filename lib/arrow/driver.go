@@ -0,0 +1,136 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// Records reads the rest of a result set as Arrow records, one record per block. A read error
+// ends the iteration and is reported by rows.Err, a conversion error by the Err method of the reader.
+func Records(rows driver.Rows) (*RecordReader, error) {
+	blocks, ok := rows.(driver.BlockReader)
+	if !ok {
+		return nil, &proto.BlockError{
+			Op:  "Records",
+			Err: fmt.Errorf("%T does not implement driver.BlockReader", rows),
+		}
+	}
+	var (
+		header  proto.Block
+		columns = rows.Columns()
+	)
+	for i, c := range rows.ColumnTypes() {
+		if err := header.AddColumn(columns[i], column.Type(c.DatabaseTypeName())); err != nil {
+			return nil, err
+		}
+	}
+	schema, err := Schema(&header)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordReader{
+		refs:   1,
+		rows:   rows,
+		blocks: blocks,
+		schema: schema,
+	}, nil
+}
+
+// RecordReader is an array.RecordReader over the blocks of a result set.
+type RecordReader struct {
+	refs   int64
+	rows   driver.Rows
+	blocks driver.BlockReader
+	schema *arrow.Schema
+	record arrow.Record
+	err    error
+}
+
+func (r *RecordReader) Retain() {
+	atomic.AddInt64(&r.refs, 1)
+}
+
+// Release closes the rows when the last reference is released.
+func (r *RecordReader) Release() {
+	if atomic.AddInt64(&r.refs, -1) == 0 {
+		if r.record != nil {
+			r.record.Release()
+			r.record = nil
+		}
+		r.rows.Close()
+	}
+}
+
+func (r *RecordReader) Schema() *arrow.Schema {
+	return r.schema
+}
+
+func (r *RecordReader) Next() bool {
+	if r.record != nil {
+		r.record.Release()
+		r.record = nil
+	}
+	if r.err != nil {
+		return false
+	}
+	for {
+		block, err := r.blocks.NextBlock()
+		switch {
+		case err != nil: // io.EOF or a read error that is reported by rows.Err
+			return false
+		case block.Rows() == 0:
+			continue
+		}
+		if r.record, err = Record(block, nil); err != nil {
+			r.err = err
+			r.rows.Close()
+			return false
+		}
+		return true
+	}
+}
+
+func (r *RecordReader) Record() arrow.Record {
+	return r.record
+}
+
+func (r *RecordReader) Err() error {
+	return r.err
+}
+
+// AppendBatch appends the rows of an Arrow record to a batch of the native interface,
+// record columns are matched by name.
+func AppendBatch(batch driver.Batch, record arrow.Record) error {
+	appender, ok := batch.(driver.BlockAppender)
+	if !ok {
+		return &proto.BlockError{
+			Op:  "AppendArrow",
+			Err: fmt.Errorf("%T does not implement driver.BlockAppender", batch),
+		}
+	}
+	return appender.AppendBlock(func(block *proto.Block) error {
+		return Append(block, record)
+	})
+}
@@ -0,0 +1,85 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	protonarrow "github.com/timeplus-io/proton-go-driver/v2/lib/arrow"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func TestRecordsAndAppendBatch(t *testing.T) {
+	block := func(n int) *proto.Block {
+		var block proto.Block
+		block.AddColumn("n", "int64")
+		for i := 0; i < n; i++ {
+			block.Append(int64(i))
+		}
+		return &block
+	}
+	received := make(chan []*proto.Block, 1)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		if strings.HasPrefix(q.Body, "INSERT") {
+			blocks, err := w.Insert(block(0))
+			received <- blocks
+			return err
+		}
+		if err := w.Data(block(0)); err != nil {
+			return err
+		}
+		if err := w.Data(block(3)); err != nil {
+			return err
+		}
+		return w.Data(block(2))
+	}))
+	defer srv.Close()
+	conn, err := proton.Open(&proton.Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	rows, err := conn.Query(ctx, "SELECT n FROM numbers")
+	require.NoError(t, err)
+	reader, err := protonarrow.Records(rows)
+	require.NoError(t, err)
+	defer reader.Release()
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO example")
+	require.NoError(t, err)
+	var ns []int64
+	for reader.Next() {
+		ns = append(ns, reader.Record().Column(0).(*array.Int64).Int64Values()...)
+		require.NoError(t, protonarrow.AppendBatch(batch, reader.Record()))
+	}
+	require.NoError(t, reader.Err())
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []int64{0, 1, 2, 0, 1}, ns)
+
+	require.NoError(t, batch.Send())
+	blocks := <-received
+	if assert.Len(t, blocks, 1) {
+		assert.Equal(t, 5, blocks[0].Rows())
+	}
+}
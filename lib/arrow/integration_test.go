@@ -0,0 +1,117 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	protonarrow "github.com/timeplus-io/proton-go-driver/v2/lib/arrow"
+)
+
+// server skips the test when no server listens on the address of the integration tests.
+func server(t *testing.T) string {
+	const addr = "127.0.0.1:8463"
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Skipf("no server at %s: %v", addr, err)
+	}
+	conn.Close()
+	return addr
+}
+
+func TestArrowIntegration(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{server(t)},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Compression: &proton.Compression{
+				Method: proton.CompressionLZ4,
+			},
+			MaxOpenConns: 1,
+		})
+	)
+	if assert.NoError(t, err) {
+		const ddl = `
+		CREATE STREAM test_arrow (
+			  Col1 int64
+			, Col2 nullable(string)
+			, Col3 array(uint32)
+			, Col4 map(string, float64)
+		)
+		`
+		defer func() {
+			conn.Exec(ctx, "DROP STREAM test_arrow")
+		}()
+		if err := conn.Exec(ctx, ddl); !assert.NoError(t, err) {
+			return
+		}
+		rows, err := conn.Query(ctx, `
+			SELECT
+				  to_int64(number)
+				, if(number % 2 = 0, NULL, to_string(number))
+				, [to_uint32(number), 1]
+				, map('k', to_float64(number))
+			FROM system.numbers LIMIT 1000
+		`)
+		if !assert.NoError(t, err) {
+			return
+		}
+		reader, err := protonarrow.Records(rows)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer reader.Release()
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_arrow (* except _tp_time)")
+		if !assert.NoError(t, err) {
+			return
+		}
+		var total int64
+		for reader.Next() {
+			record := reader.Record()
+			ids := record.Column(0).(*array.Int64)
+			for i := 0; i < ids.Len(); i++ {
+				assert.Equal(t, total+int64(i), ids.Value(i))
+				assert.Equal(t, ids.Value(i)%2 == 0, record.Column(1).IsNull(i))
+			}
+			total += record.NumRows()
+			if !assert.NoError(t, protonarrow.AppendBatch(batch, record)) {
+				return
+			}
+		}
+		if !assert.NoError(t, rows.Err()) || !assert.NoError(t, reader.Err()) || !assert.Equal(t, int64(1000), total) {
+			return
+		}
+		if assert.NoError(t, batch.Send()) {
+			var count uint64
+			if err := conn.QueryRow(ctx, "SELECT count() FROM table(test_arrow) WHERE Col2 IS NULL").Scan(&count); assert.NoError(t, err) {
+				assert.Equal(t, uint64(500), count)
+			}
+		}
+	}
+}
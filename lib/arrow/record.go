@@ -0,0 +1,303 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package arrow
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/decimal128"
+	"github.com/apache/arrow/go/v10/arrow/decimal256"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// Record converts a block into an Arrow record, the caller must Release it.
+// A nil allocator means memory.DefaultAllocator.
+func Record(block *proto.Block, mem memory.Allocator) (arrow.Record, error) {
+	schema, err := Schema(block)
+	if err != nil {
+		return nil, err
+	}
+	if mem == nil {
+		mem = memory.DefaultAllocator
+	}
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+	builder.Reserve(block.Rows())
+	for i, c := range block.Columns {
+		if err := appendColumn(builder.Field(i), c); err != nil {
+			return nil, &proto.BlockError{
+				Op:         "ArrowRecord",
+				Err:        err,
+				ColumnName: block.ColumnsNames()[i],
+			}
+		}
+	}
+	return builder.NewRecord(), nil
+}
+
+func appendColumn(b array.Builder, col column.Interface) error {
+	var valid []bool
+	if nulls := column.Nulls(col); nulls != nil {
+		valid = make([]bool, len(nulls))
+		for i, null := range nulls {
+			valid[i] = !null
+		}
+	}
+	// fast path: fixed width columns are appended as a whole
+	switch b := b.(type) {
+	case *array.Int8Builder:
+		if v, err := column.Int8s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Int16Builder:
+		if v, err := column.Int16s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Int32Builder:
+		if v, err := column.Int32s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Int64Builder:
+		if v, err := column.Int64s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Uint8Builder:
+		if v, err := column.UInt8s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Uint16Builder:
+		if v, err := column.UInt16s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Uint32Builder:
+		if v, err := column.UInt32s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Uint64Builder:
+		if v, err := column.UInt64s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Float32Builder:
+		if v, err := column.Float32s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.Float64Builder:
+		if v, err := column.Float64s(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	case *array.StringBuilder:
+		if v, err := column.Strings(col); err == nil {
+			b.AppendValues(v, valid)
+			return nil
+		}
+	}
+	for i := 0; i < col.Rows(); i++ {
+		if err := appendValue(b, col.Row(i, false)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendValue(b array.Builder, v interface{}) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			b.AppendNull()
+			return nil
+		}
+		value = value.Elem()
+		v = value.Interface()
+	}
+	var ok bool
+	switch b := b.(type) {
+	case *array.Int8Builder:
+		var x int8
+		if x, ok = v.(int8); ok {
+			b.Append(x)
+		}
+	case *array.Int16Builder:
+		var x int16
+		if x, ok = v.(int16); ok {
+			b.Append(x)
+		}
+	case *array.Int32Builder:
+		var x int32
+		if x, ok = v.(int32); ok {
+			b.Append(x)
+		}
+	case *array.Int64Builder:
+		var x int64
+		if x, ok = v.(int64); ok {
+			b.Append(x)
+		}
+	case *array.Uint8Builder:
+		var x uint8
+		if x, ok = v.(uint8); ok {
+			b.Append(x)
+		}
+	case *array.Uint16Builder:
+		var x uint16
+		if x, ok = v.(uint16); ok {
+			b.Append(x)
+		}
+	case *array.Uint32Builder:
+		var x uint32
+		if x, ok = v.(uint32); ok {
+			b.Append(x)
+		}
+	case *array.Uint64Builder:
+		var x uint64
+		if x, ok = v.(uint64); ok {
+			b.Append(x)
+		}
+	case *array.Float32Builder:
+		var x float32
+		if x, ok = v.(float32); ok {
+			b.Append(x)
+		}
+	case *array.Float64Builder:
+		var x float64
+		if x, ok = v.(float64); ok {
+			b.Append(x)
+		}
+	case *array.StringBuilder:
+		var x string
+		if x, ok = v.(string); ok {
+			b.Append(x)
+		}
+	case *array.BooleanBuilder:
+		var x bool
+		if x, ok = v.(bool); ok {
+			b.Append(x)
+		}
+	case *array.Date32Builder:
+		var x time.Time
+		if x, ok = v.(time.Time); ok {
+			b.Append(arrow.Date32(x.Unix() / (24 * 60 * 60)))
+		}
+	case *array.TimestampBuilder:
+		var x time.Time
+		if x, ok = v.(time.Time); ok {
+			switch b.Type().(*arrow.TimestampType).Unit {
+			case arrow.Second:
+				b.Append(arrow.Timestamp(x.Unix()))
+			case arrow.Millisecond:
+				b.Append(arrow.Timestamp(x.UnixMilli()))
+			case arrow.Microsecond:
+				b.Append(arrow.Timestamp(x.UnixMicro()))
+			default:
+				b.Append(arrow.Timestamp(x.UnixNano()))
+			}
+		}
+	case *array.Decimal128Builder:
+		var x decimal.Decimal
+		if x, ok = v.(decimal.Decimal); ok {
+			scale := b.Type().(*arrow.Decimal128Type).Scale
+			b.Append(decimal128.FromBigInt(x.Shift(scale).BigInt()))
+		}
+	case *array.Decimal256Builder:
+		var x decimal.Decimal
+		if x, ok = v.(decimal.Decimal); ok {
+			scale := b.Type().(*arrow.Decimal256Type).Scale
+			b.Append(decimal256.FromBigInt(x.Shift(scale).BigInt()))
+		}
+	case *array.FixedSizeBinaryBuilder:
+		width := b.Type().(*arrow.FixedSizeBinaryType).ByteWidth
+		switch x := v.(type) {
+		case uuid.UUID:
+			b.Append(x[:])
+			ok = true
+		case net.IP:
+			if width == net.IPv4len {
+				x = x.To4()
+			}
+			if ok = len(x) == width; ok {
+				b.Append(x)
+			}
+		case string:
+			data := make([]byte, width)
+			copy(data, x)
+			b.Append(data)
+			ok = true
+		}
+	case *array.ListBuilder:
+		if ok = value.Kind() == reflect.Slice; ok {
+			b.Append(true)
+			for i := 0; i < value.Len(); i++ {
+				if err := appendValue(b.ValueBuilder(), value.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+		}
+	case *array.MapBuilder:
+		if ok = value.Kind() == reflect.Map; ok {
+			b.Append(true)
+			keys := value.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, key := range keys {
+				if err := appendValue(b.KeyBuilder(), key.Interface()); err != nil {
+					return err
+				}
+				if err := appendValue(b.ItemBuilder(), value.MapIndex(key).Interface()); err != nil {
+					return err
+				}
+			}
+		}
+	case *array.StructBuilder:
+		var x []interface{}
+		if x, ok = v.([]interface{}); ok && len(x) == b.NumField() {
+			b.Append(true)
+			for i, e := range x {
+				if err := appendValue(b.FieldBuilder(i), e); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("converting %T to %s is unsupported", v, b.Type())
+	}
+	return nil
+}
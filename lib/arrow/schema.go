@@ -0,0 +1,282 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package arrow converts proto.Block to Apache Arrow records and back.
+//
+// Type mapping:
+//
+//	int8 ... uint64, float32/64   -> Int8 ... Uint64, Float32/64
+//	string, enum8/16              -> String
+//	fixed_string(N)               -> FixedSizeBinary(N)
+//	bool                          -> Boolean
+//	date, date32                  -> Date32
+//	datetime(tz)                  -> Timestamp(s, tz)
+//	datetime64(P, tz)             -> Timestamp(s/ms/us/ns, tz)
+//	decimal(P, S)                 -> Decimal128(P, S), Decimal256(P, S) when P > 38
+//	uuid, ipv6                    -> FixedSizeBinary(16)
+//	ipv4                          -> FixedSizeBinary(4)
+//	nullable(T)                   -> nullable field of T
+//	low_cardinality(T)            -> T (values are materialized)
+//	array(T)                      -> List(T)
+//	map(K, V)                     -> Map(K, V)
+//	tuple(T1, T2...)              -> Struct
+//
+// The original Proton type is kept in the field metadata under MetadataType,
+// so a record produced by this package converts back to the same block.
+package arrow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+const MetadataType = "proton.type"
+
+type UnsupportedTypeError struct {
+	Type string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("proton [arrow]: unsupported type %s", e.Type)
+}
+
+// Schema returns the Arrow schema of a block.
+func Schema(block *proto.Block) (*arrow.Schema, error) {
+	var (
+		names  = block.ColumnsNames()
+		fields = make([]arrow.Field, 0, len(block.Columns))
+	)
+	for i, c := range block.Columns {
+		dt, nullable, err := dataType(c)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, arrow.Field{
+			Name:     names[i],
+			Type:     dt,
+			Nullable: nullable,
+			Metadata: arrow.NewMetadata([]string{MetadataType}, []string{string(c.Type())}),
+		})
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func dataType(col column.Interface) (_ arrow.DataType, nullable bool, err error) {
+	switch col := col.(type) {
+	case *column.Nullable:
+		dt, _, err := dataType(col.Base())
+		return dt, true, err
+	case *column.LowCardinality:
+		return dataType(col.Base())
+	case *column.Int8:
+		return arrow.PrimitiveTypes.Int8, false, nil
+	case *column.Int16:
+		return arrow.PrimitiveTypes.Int16, false, nil
+	case *column.Int32:
+		return arrow.PrimitiveTypes.Int32, false, nil
+	case *column.Int64:
+		return arrow.PrimitiveTypes.Int64, false, nil
+	case *column.UInt8:
+		return arrow.PrimitiveTypes.Uint8, false, nil
+	case *column.UInt16:
+		return arrow.PrimitiveTypes.Uint16, false, nil
+	case *column.UInt32:
+		return arrow.PrimitiveTypes.Uint32, false, nil
+	case *column.UInt64:
+		return arrow.PrimitiveTypes.Uint64, false, nil
+	case *column.Float32:
+		return arrow.PrimitiveTypes.Float32, false, nil
+	case *column.Float64:
+		return arrow.PrimitiveTypes.Float64, false, nil
+	case *column.String, *column.Enum8, *column.Enum16:
+		return arrow.BinaryTypes.String, false, nil
+	case *column.FixedString:
+		return &arrow.FixedSizeBinaryType{ByteWidth: col.Size()}, false, nil
+	case *column.Bool:
+		return arrow.FixedWidthTypes.Boolean, false, nil
+	case *column.Date, *column.Date32:
+		return arrow.FixedWidthTypes.Date32, false, nil
+	case *column.DateTime:
+		return &arrow.TimestampType{Unit: arrow.Second, TimeZone: timezone(col.Location())}, false, nil
+	case *column.DateTime64:
+		return &arrow.TimestampType{Unit: timeUnit(col.Precision()), TimeZone: timezone(col.Location())}, false, nil
+	case *column.Decimal:
+		if col.Precision() > 38 {
+			return &arrow.Decimal256Type{Precision: int32(col.Precision()), Scale: int32(col.Scale())}, false, nil
+		}
+		return &arrow.Decimal128Type{Precision: int32(col.Precision()), Scale: int32(col.Scale())}, false, nil
+	case *column.UUID, *column.IPv6:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 16}, false, nil
+	case *column.IPv4:
+		return &arrow.FixedSizeBinaryType{ByteWidth: 4}, false, nil
+	case *column.Array:
+		dt, nullable, err := dataType(col.Base())
+		if err != nil {
+			return nil, false, err
+		}
+		for i := 0; i < col.Depth(); i++ {
+			dt, nullable = arrow.ListOfField(arrow.Field{Name: "item", Type: dt, Nullable: nullable}), false
+		}
+		return dt, false, nil
+	case *column.Map:
+		key, _, err := dataType(col.Key())
+		if err != nil {
+			return nil, false, err
+		}
+		value, _, err := dataType(col.Value())
+		if err != nil {
+			return nil, false, err
+		}
+		return arrow.MapOf(key, value), false, nil
+	case *column.Tuple:
+		var (
+			names  = col.Names()
+			fields = make([]arrow.Field, 0, len(col.Columns()))
+		)
+		for i, c := range col.Columns() {
+			dt, nullable, err := dataType(c)
+			if err != nil {
+				return nil, false, err
+			}
+			name := names[i]
+			if len(name) == 0 {
+				name = fmt.Sprintf("_%d", i+1)
+			}
+			fields = append(fields, arrow.Field{Name: name, Type: dt, Nullable: nullable})
+		}
+		return arrow.StructOf(fields...), false, nil
+	}
+	return nil, false, &UnsupportedTypeError{
+		Type: string(col.Type()),
+	}
+}
+
+// columnType is the reverse of dataType for records that were not produced by this package.
+func columnType(dt arrow.DataType, nullable bool) (column.Type, error) {
+	var t string
+	switch dt := dt.(type) {
+	case *arrow.Int8Type:
+		t = "int8"
+	case *arrow.Int16Type:
+		t = "int16"
+	case *arrow.Int32Type:
+		t = "int32"
+	case *arrow.Int64Type:
+		t = "int64"
+	case *arrow.Uint8Type:
+		t = "uint8"
+	case *arrow.Uint16Type:
+		t = "uint16"
+	case *arrow.Uint32Type:
+		t = "uint32"
+	case *arrow.Uint64Type:
+		t = "uint64"
+	case *arrow.Float32Type:
+		t = "float32"
+	case *arrow.Float64Type:
+		t = "float64"
+	case *arrow.StringType, *arrow.BinaryType:
+		t = "string"
+	case *arrow.FixedSizeBinaryType:
+		t = fmt.Sprintf("fixed_string(%d)", dt.ByteWidth)
+	case *arrow.BooleanType:
+		t = "bool"
+	case *arrow.Date32Type, *arrow.Date64Type:
+		t = "date32"
+	case *arrow.TimestampType:
+		var precision int
+		switch dt.Unit {
+		case arrow.Millisecond:
+			precision = 3
+		case arrow.Microsecond:
+			precision = 6
+		case arrow.Nanosecond:
+			precision = 9
+		}
+		if t = fmt.Sprintf("datetime64(%d)", precision); len(dt.TimeZone) != 0 {
+			t = fmt.Sprintf("datetime64(%d, '%s')", precision, dt.TimeZone)
+		}
+	case *arrow.Decimal128Type:
+		t = fmt.Sprintf("decimal(%d, %d)", dt.Precision, dt.Scale)
+	case *arrow.Decimal256Type:
+		t = fmt.Sprintf("decimal(%d, %d)", dt.Precision, dt.Scale)
+	case *arrow.DictionaryType:
+		base, err := columnType(dt.ValueType, nullable)
+		if err != nil {
+			return "", err
+		}
+		return column.Type("low_cardinality(" + string(base) + ")"), nil
+	case *arrow.ListType:
+		elem, err := columnType(dt.Elem(), dt.ElemField().Nullable)
+		if err != nil {
+			return "", err
+		}
+		return column.Type("array(" + string(elem) + ")"), nil
+	case *arrow.MapType:
+		key, err := columnType(dt.KeyType(), false)
+		if err != nil {
+			return "", err
+		}
+		value, err := columnType(dt.ItemType(), dt.ItemField().Nullable)
+		if err != nil {
+			return "", err
+		}
+		return column.Type("map(" + string(key) + ", " + string(value) + ")"), nil
+	case *arrow.StructType:
+		elements := make([]string, 0, len(dt.Fields()))
+		for _, f := range dt.Fields() {
+			elem, err := columnType(f.Type, f.Nullable)
+			if err != nil {
+				return "", err
+			}
+			elements = append(elements, f.Name+" "+string(elem))
+		}
+		return column.Type("tuple(" + strings.Join(elements, ", ") + ")"), nil
+	default:
+		return "", &UnsupportedTypeError{
+			Type: dt.String(),
+		}
+	}
+	if nullable {
+		return column.Type("nullable(" + t + ")"), nil
+	}
+	return column.Type(t), nil
+}
+
+func timeUnit(precision int) arrow.TimeUnit {
+	switch {
+	case precision == 0:
+		return arrow.Second
+	case precision <= 3:
+		return arrow.Millisecond
+	case precision <= 6:
+		return arrow.Microsecond
+	}
+	return arrow.Nanosecond
+}
+
+func timezone(loc *time.Location) string {
+	if loc == nil {
+		return ""
+	}
+	return loc.String()
+}
@@ -79,6 +79,11 @@ func (col *Array) Base() Interface {
 	return col.values
 }
 
+// Depth is the number of nested arrays, e.g. 2 for array(array(T)).
+func (col *Array) Depth() int {
+	return col.depth
+}
+
 func (col *Array) Type() Type {
 	return col.chType
 }
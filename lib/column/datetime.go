@@ -50,6 +50,10 @@ func (dt *DateTime) parse(t Type) (_ *DateTime, err error) {
 	return dt, nil
 }
 
+func (dt *DateTime) Location() *time.Location {
+	return dt.timezone
+}
+
 func (dt *DateTime) Type() Type {
 	return dt.chType
 }
@@ -64,6 +64,14 @@ func (dt *DateTime64) parse(t Type) (_ Interface, err error) {
 	return dt, nil
 }
 
+func (dt *DateTime64) Precision() int {
+	return dt.precision
+}
+
+func (dt *DateTime64) Location() *time.Location {
+	return dt.timezone
+}
+
 func (dt *DateTime64) Type() Type {
 	return dt.chType
 }
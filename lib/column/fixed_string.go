@@ -37,6 +37,10 @@ func (col *FixedString) parse(t Type) (*FixedString, error) {
 	return col, nil
 }
 
+func (col *FixedString) Size() int {
+	return col.size
+}
+
 func (col *FixedString) Type() Type {
 	return Type(fmt.Sprintf("fixed_string(%d)", col.size))
 }
@@ -80,6 +80,10 @@ func (col *LowCardinality) parse(t Type) (_ *LowCardinality, err error) {
 	return col, nil
 }
 
+func (col *LowCardinality) Base() Interface {
+	return col.index
+}
+
 func (col *LowCardinality) Type() Type {
 	return col.chType
 }
@@ -278,6 +282,9 @@ func (col *LowCardinality) keys() Interface {
 }
 
 func (col *LowCardinality) indexRowNum(row int) int {
+	if row < len(col.append.keys) { // appended rows are not encoded yet
+		return col.append.keys[row]
+	}
 	switch v := col.keys().Row(row, false).(type) {
 	case uint8:
 		return int(v)
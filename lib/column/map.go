@@ -54,6 +54,14 @@ func (col *Map) parse(t Type) (_ Interface, err error) {
 	}
 }
 
+func (col *Map) Key() Interface {
+	return col.keys
+}
+
+func (col *Map) Value() Interface {
+	return col.values
+}
+
 func (col *Map) Type() Type {
 	return col.chType
 }
@@ -27,6 +27,7 @@ import (
 
 type Tuple struct {
 	chType  Type
+	names   []string
 	columns []Interface
 }
 
@@ -34,17 +35,21 @@ func (col *Tuple) parse(t Type) (_ Interface, err error) {
 	col.chType = t
 	var (
 		element       []rune
+		names         []string
 		elements      []string
 		brackets      int
 		appendElement = func() {
 			if len(element) != 0 {
-				name := strings.TrimSpace(string(element))
-				if parts := strings.SplitN(name, " ", 2); len(parts) == 2 {
+				var (
+					name     string
+					typeName = strings.TrimSpace(string(element))
+				)
+				if parts := strings.SplitN(typeName, " ", 2); len(parts) == 2 {
 					if !strings.Contains(parts[0], "(") {
-						name = parts[1]
+						name, typeName = parts[0], parts[1]
 					}
 				}
-				elements = append(elements, name)
+				names, elements = append(names, name), append(elements, typeName)
 			}
 		}
 	)
@@ -71,6 +76,7 @@ func (col *Tuple) parse(t Type) (_ Interface, err error) {
 		}
 		col.columns = append(col.columns, column)
 	}
+	col.names = names
 	if len(col.columns) != 0 {
 		return col, nil
 	}
@@ -79,6 +85,16 @@ func (col *Tuple) parse(t Type) (_ Interface, err error) {
 	}
 }
 
+// Columns returns the element columns of the tuple.
+func (col *Tuple) Columns() []Interface {
+	return col.columns
+}
+
+// Names returns the element names of a named tuple, unnamed elements have an empty name.
+func (col *Tuple) Names() []string {
+	return col.names
+}
+
 func (col *Tuple) Type() Type {
 	return col.chType
 }
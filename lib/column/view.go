@@ -0,0 +1,221 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package column
+
+import (
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// The view functions return the values of a whole column as a typed slice.
+//
+// Numeric, decimal and string views share memory with the column: they are only valid
+// while the block they come from is, and must be copied to be retained or modified.
+// Bool, time, UUID and IP views are converted and therefore always freshly allocated.
+//
+// A view of a nullable column is the view of its base column; the value of a NULL
+// row is the zero value of the type, see Nulls.
+
+func Int8s(col Interface) ([]int8, error) {
+	if v, ok := viewBase(col).(*Int8); ok {
+		return *v, nil
+	}
+	return nil, viewError("Int8s", "[]int8", col)
+}
+
+func Int16s(col Interface) ([]int16, error) {
+	if v, ok := viewBase(col).(*Int16); ok {
+		return *v, nil
+	}
+	return nil, viewError("Int16s", "[]int16", col)
+}
+
+func Int32s(col Interface) ([]int32, error) {
+	if v, ok := viewBase(col).(*Int32); ok {
+		return *v, nil
+	}
+	return nil, viewError("Int32s", "[]int32", col)
+}
+
+func Int64s(col Interface) ([]int64, error) {
+	if v, ok := viewBase(col).(*Int64); ok {
+		return *v, nil
+	}
+	return nil, viewError("Int64s", "[]int64", col)
+}
+
+func UInt8s(col Interface) ([]uint8, error) {
+	if v, ok := viewBase(col).(*UInt8); ok {
+		return *v, nil
+	}
+	return nil, viewError("UInt8s", "[]uint8", col)
+}
+
+func UInt16s(col Interface) ([]uint16, error) {
+	if v, ok := viewBase(col).(*UInt16); ok {
+		return *v, nil
+	}
+	return nil, viewError("UInt16s", "[]uint16", col)
+}
+
+func UInt32s(col Interface) ([]uint32, error) {
+	if v, ok := viewBase(col).(*UInt32); ok {
+		return *v, nil
+	}
+	return nil, viewError("UInt32s", "[]uint32", col)
+}
+
+func UInt64s(col Interface) ([]uint64, error) {
+	if v, ok := viewBase(col).(*UInt64); ok {
+		return *v, nil
+	}
+	return nil, viewError("UInt64s", "[]uint64", col)
+}
+
+func Float32s(col Interface) ([]float32, error) {
+	if v, ok := viewBase(col).(*Float32); ok {
+		return *v, nil
+	}
+	return nil, viewError("Float32s", "[]float32", col)
+}
+
+func Float64s(col Interface) ([]float64, error) {
+	if v, ok := viewBase(col).(*Float64); ok {
+		return *v, nil
+	}
+	return nil, viewError("Float64s", "[]float64", col)
+}
+
+func Strings(col Interface) ([]string, error) {
+	if v, ok := viewBase(col).(*String); ok {
+		return *v, nil
+	}
+	return nil, viewError("Strings", "[]string", col)
+}
+
+func Bools(col Interface) ([]bool, error) {
+	v, ok := viewBase(col).(*Bool)
+	if !ok {
+		return nil, viewError("Bools", "[]bool", col)
+	}
+	values := make([]bool, len(v.values))
+	for i := range v.values {
+		values[i] = v.row(i)
+	}
+	return values, nil
+}
+
+func Decimals(col Interface) ([]decimal.Decimal, error) {
+	if v, ok := viewBase(col).(*Decimal); ok {
+		return v.values, nil
+	}
+	return nil, viewError("Decimals", "[]decimal.Decimal", col)
+}
+
+// Times supports date, date32, datetime and datetime64 columns.
+func Times(col Interface) ([]time.Time, error) {
+	switch v := viewBase(col).(type) {
+	case *Date:
+		values := make([]time.Time, len(v.values))
+		for i := range v.values {
+			values[i] = v.row(i).Time
+		}
+		return values, nil
+	case *Date32:
+		values := make([]time.Time, len(v.values))
+		for i := range v.values {
+			values[i] = v.row(i).Time
+		}
+		return values, nil
+	case *DateTime:
+		values := make([]time.Time, len(v.values))
+		for i := range v.values {
+			values[i] = v.row(i)
+		}
+		return values, nil
+	case *DateTime64:
+		values := make([]time.Time, len(v.values))
+		for i := range v.values {
+			values[i] = v.row(i)
+		}
+		return values, nil
+	}
+	return nil, viewError("Times", "[]time.Time", col)
+}
+
+func UUIDs(col Interface) ([]uuid.UUID, error) {
+	v, ok := viewBase(col).(*UUID)
+	if !ok {
+		return nil, viewError("UUIDs", "[]uuid.UUID", col)
+	}
+	values := make([]uuid.UUID, v.Rows())
+	for i := range values {
+		values[i] = v.row(i)
+	}
+	return values, nil
+}
+
+// IPs supports ipv4 and ipv6 columns.
+func IPs(col Interface) ([]net.IP, error) {
+	switch v := viewBase(col).(type) {
+	case *IPv4:
+		values := make([]net.IP, v.Rows())
+		for i := range values {
+			values[i] = v.row(i)
+		}
+		return values, nil
+	case *IPv6:
+		values := make([]net.IP, v.Rows())
+		for i := range values {
+			values[i] = append(net.IP(nil), v.row(i)...)
+		}
+		return values, nil
+	}
+	return nil, viewError("IPs", "[]net.IP", col)
+}
+
+// Nulls returns the null map of a nullable column (true means NULL) or nil for any other column.
+func Nulls(col Interface) []bool {
+	v, ok := col.(*Nullable)
+	if !ok || !v.enable {
+		return nil
+	}
+	nulls := make([]bool, len(v.nulls))
+	for i, null := range v.nulls {
+		nulls[i] = null == 1
+	}
+	return nulls
+}
+
+func viewBase(col Interface) Interface {
+	if v, ok := col.(*Nullable); ok {
+		return v.base
+	}
+	return col
+}
+
+func viewError(op, to string, col Interface) error {
+	return &ColumnConverterError{
+		Op:   op,
+		To:   to,
+		From: string(col.Type()),
+	}
+}
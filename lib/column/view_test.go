@@ -0,0 +1,166 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package column
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newColumn(t *testing.T, typ Type, values ...interface{}) Interface {
+	col, err := typ.Column()
+	require.NoError(t, err)
+	for _, v := range values {
+		require.NoError(t, col.AppendRow(v))
+	}
+	return col
+}
+
+func TestNumericViews(t *testing.T) {
+	int8s, err := Int8s(newColumn(t, "int8", int8(1), int8(-2)))
+	require.NoError(t, err)
+	assert.Equal(t, []int8{1, -2}, int8s)
+	int16s, err := Int16s(newColumn(t, "int16", int16(1), int16(-2)))
+	require.NoError(t, err)
+	assert.Equal(t, []int16{1, -2}, int16s)
+	int32s, err := Int32s(newColumn(t, "int32", int32(1), int32(-2)))
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, -2}, int32s)
+	int64s, err := Int64s(newColumn(t, "int64", int64(1), int64(-2)))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, -2}, int64s)
+	uint8s, err := UInt8s(newColumn(t, "uint8", uint8(1), uint8(2)))
+	require.NoError(t, err)
+	assert.Equal(t, []uint8{1, 2}, uint8s)
+	uint16s, err := UInt16s(newColumn(t, "uint16", uint16(1), uint16(2)))
+	require.NoError(t, err)
+	assert.Equal(t, []uint16{1, 2}, uint16s)
+	uint32s, err := UInt32s(newColumn(t, "uint32", uint32(1), uint32(2)))
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1, 2}, uint32s)
+	uint64s, err := UInt64s(newColumn(t, "uint64", uint64(1), uint64(2)))
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, uint64s)
+	float32s, err := Float32s(newColumn(t, "float32", float32(1.5), float32(-2)))
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1.5, -2}, float32s)
+	float64s, err := Float64s(newColumn(t, "float64", 1.5, -2.0))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1.5, -2}, float64s)
+}
+
+func TestViewsShareMemory(t *testing.T) {
+	col := newColumn(t, "int64", int64(1), int64(2))
+	values, err := Int64s(col)
+	require.NoError(t, err)
+	values[0] = 10
+	assert.Equal(t, int64(10), col.Row(0, false))
+}
+
+func TestStringAndDecimalViews(t *testing.T) {
+	stringValues, err := Strings(newColumn(t, "string", "a", ""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", ""}, stringValues)
+	bools, err := Bools(newColumn(t, "bool", true, false))
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false}, bools)
+	decimals, err := Decimals(newColumn(t, "decimal(10, 2)", decimal.RequireFromString("1.25"), decimal.RequireFromString("-3")))
+	require.NoError(t, err)
+	if assert.Len(t, decimals, 2) {
+		assert.True(t, decimal.RequireFromString("1.25").Equal(decimals[0]))
+		assert.True(t, decimal.RequireFromString("-3").Equal(decimals[1]))
+	}
+}
+
+func TestTimeViews(t *testing.T) {
+	var (
+		day  = time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC)
+		tm   = time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+		tm64 = time.Date(2022, 3, 4, 5, 6, 7, 123000000, time.UTC)
+	)
+	for typ, expected := range map[Type]time.Time{
+		"date":          day,
+		"date32":        day,
+		"datetime":      tm,
+		"datetime64(3)": tm64,
+	} {
+		times, err := Times(newColumn(t, typ, expected))
+		require.NoError(t, err, typ)
+		if assert.Len(t, times, 1, typ) {
+			assert.True(t, expected.Equal(times[0]), "%s: %s", typ, times[0])
+		}
+	}
+}
+
+func TestUUIDAndIPViews(t *testing.T) {
+	id := uuid.New()
+	uuids, err := UUIDs(newColumn(t, "uuid", id))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{id}, uuids)
+	ipv4s, err := IPs(newColumn(t, "ipv4", net.ParseIP("127.0.0.1")))
+	require.NoError(t, err)
+	if assert.Len(t, ipv4s, 1) {
+		assert.True(t, net.ParseIP("127.0.0.1").Equal(ipv4s[0]))
+	}
+	ipv6s, err := IPs(newColumn(t, "ipv6", net.ParseIP("::1")))
+	require.NoError(t, err)
+	if assert.Len(t, ipv6s, 1) {
+		assert.True(t, net.ParseIP("::1").Equal(ipv6s[0]))
+	}
+}
+
+func TestNullableViews(t *testing.T) {
+	one := int64(1)
+	col := newColumn(t, "nullable(int64)", &one, nil, int64(3))
+	values, err := Int64s(col)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 0, 3}, values)
+	assert.Equal(t, []bool{false, true, false}, Nulls(col))
+	assert.Nil(t, Nulls(newColumn(t, "int64", int64(1))))
+}
+
+func TestViewTypeMismatch(t *testing.T) {
+	col := newColumn(t, "int32", int32(1))
+	for name, view := range map[string]func(Interface) error{
+		"Int8s":    func(col Interface) error { _, err := Int8s(col); return err },
+		"Int64s":   func(col Interface) error { _, err := Int64s(col); return err },
+		"UInt32s":  func(col Interface) error { _, err := UInt32s(col); return err },
+		"Float64s": func(col Interface) error { _, err := Float64s(col); return err },
+		"Strings":  func(col Interface) error { _, err := Strings(col); return err },
+		"Bools":    func(col Interface) error { _, err := Bools(col); return err },
+		"Decimals": func(col Interface) error { _, err := Decimals(col); return err },
+		"Times":    func(col Interface) error { _, err := Times(col); return err },
+		"UUIDs":    func(col Interface) error { _, err := UUIDs(col); return err },
+		"IPs":      func(col Interface) error { _, err := IPs(col); return err },
+	} {
+		var converterErr *ColumnConverterError
+		if assert.True(t, errors.As(view(col), &converterErr), name) {
+			assert.Equal(t, name, converterErr.Op)
+			assert.Equal(t, "int32", converterErr.From)
+		}
+	}
+	_, err := Int64s(newColumn(t, "nullable(int32)", int32(1)))
+	assert.Error(t, err)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 )
 
@@ -40,6 +41,7 @@ type Reader struct {
 	data   []byte
 	zdata  []byte
 	header []byte
+	zstd   *zstd.Decoder
 }
 
 func (r *Reader) Read(p []byte) (int, error) {
@@ -71,7 +73,7 @@ func (r *Reader) readBlock() (err error) {
 		return
 	}
 	if n != len(r.header) {
-		return fmt.Errorf("LZ4 decompression header EOF")
+		return fmt.Errorf("decompression header EOF")
 	}
 	var (
 		compressedSize   = int(endian.Uint32(r.header[17:])) - 9
@@ -86,8 +88,8 @@ func (r *Reader) readBlock() (err error) {
 
 	r.data, r.zdata = r.data[:decompressedSize], r.zdata[:compressedSize]
 
-	switch r.header[16] {
-	case LZ4:
+	switch Method(r.header[16]) {
+	case NONE, LZ4, ZSTD:
 	default:
 		return fmt.Errorf("unknown compression method: 0x%02x ", r.header[16])
 	}
@@ -98,13 +100,37 @@ func (r *Reader) readBlock() (err error) {
 	if n != len(r.zdata) {
 		return fmt.Errorf("decompress read size not match")
 	}
-	if _, err = lz4.UncompressBlock(r.zdata, r.data); err != nil {
-		return
+	// every block carries its own method, so blocks of a stream may be compressed differently
+	switch Method(r.header[16]) {
+	case NONE:
+		copy(r.data, r.zdata)
+	case LZ4:
+		if _, err = lz4.UncompressBlock(r.zdata, r.data); err != nil {
+			return
+		}
+	case ZSTD:
+		if r.zstd == nil {
+			if r.zstd, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+				return
+			}
+		}
+		var data []byte
+		if data, err = r.zstd.DecodeAll(r.zdata, r.data[:0]); err != nil {
+			return
+		}
+		if len(data) != decompressedSize {
+			return fmt.Errorf("decompressed size not match: %d != %d", len(data), decompressedSize)
+		}
+		r.data = data
 	}
 	return nil
 }
 
 func (r *Reader) Close() error {
+	if r.zstd != nil {
+		r.zstd.Close()
+		r.zstd = nil
+	}
 	r.data = nil
 	r.zdata = nil
 	return nil
@@ -0,0 +1,107 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package compress
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testData(size int) []byte {
+	var (
+		data = make([]byte, 0, size)
+		rnd  = rand.New(rand.NewSource(42))
+	)
+	for len(data) < size {
+		data = strconv.AppendInt(append(data, "value="...), rnd.Int63n(1000), 10)
+	}
+	return data[:size]
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		method Method
+		level  int
+	}{
+		{"lz4", LZ4, 0},
+		{"zstd", ZSTD, 0},
+		{"zstd level 1", ZSTD, 1},
+		{"zstd level 19", ZSTD, 19},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var (
+				buf  bytes.Buffer
+				data = testData(3*maxBlockSize + 123)
+				w    = NewWriterLevel(&buf, c.method, c.level)
+			)
+			_, err := w.Write(data)
+			require.NoError(t, err)
+			require.NoError(t, w.Flush())
+			assert.Less(t, buf.Len(), len(data))
+			assert.Equal(t, byte(c.method), buf.Bytes()[checksumSize])
+
+			r := NewReader(&buf)
+			out := make([]byte, len(data))
+			_, err = io.ReadFull(r, out)
+			require.NoError(t, err)
+			assert.Equal(t, data, out)
+		})
+	}
+}
+
+func TestMixedMethods(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		blocks [][]byte
+	)
+	for i, method := range []Method{LZ4, ZSTD, LZ4, ZSTD, ZSTD} {
+		data := testData(1000 * (i + 1))
+		w := NewWriterLevel(&buf, method, 3)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Flush())
+		require.NoError(t, w.Close())
+		blocks = append(blocks, data)
+	}
+	r := NewReader(&buf)
+	defer r.Close()
+	for _, data := range blocks {
+		out := make([]byte, len(data))
+		_, err := io.ReadFull(r, out)
+		require.NoError(t, err)
+		assert.Equal(t, data, out)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	_, err := w.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+	buf.Bytes()[checksumSize] = 0x42
+	_, err = io.ReadFull(NewReader(&buf), make([]byte, 4))
+	assert.Error(t, err)
+}
@@ -18,17 +18,28 @@
 package compress
 
 import (
+	"fmt"
 	"io"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/cityhash102"
 )
 
+// NewWriter returns a writer that compresses blocks with LZ4.
 func NewWriter(wr io.Writer) *Writer {
+	return NewWriterLevel(wr, LZ4, 0)
+}
+
+// NewWriterLevel returns a writer that compresses blocks with the given method (LZ4 or ZSTD).
+// level is the ZSTD compression level (1-22, 0 means the default), it is ignored for LZ4.
+func NewWriterLevel(wr io.Writer, method Method, level int) *Writer {
 	return &Writer{
-		wr:    wr,
-		data:  make([]byte, maxBlockSize),
-		zdata: make([]byte, lz4.CompressBlockBound(maxBlockSize)+headerSize),
+		wr:     wr,
+		data:   make([]byte, maxBlockSize),
+		zdata:  make([]byte, lz4.CompressBlockBound(maxBlockSize)+headerSize),
+		level:  level,
+		method: method,
 	}
 }
 
@@ -37,7 +48,10 @@ type Writer struct {
 	pos        int
 	data       []byte
 	zdata      []byte
+	level      int
+	method     Method
 	compressor lz4.Compressor
+	zstd       *zstd.Encoder
 }
 
 func (w *Writer) Write(p []byte) (n int, err error) {
@@ -59,13 +73,32 @@ func (w *Writer) Flush() (err error) {
 	if w.pos == 0 {
 		return
 	}
-	compressedSize, err := w.compressor.CompressBlock(w.data[:w.pos], w.zdata[headerSize:])
-	if err != nil {
-		return err
+	var compressedSize int
+	switch w.method {
+	case LZ4:
+		if compressedSize, err = w.compressor.CompressBlock(w.data[:w.pos], w.zdata[headerSize:]); err != nil {
+			return err
+		}
+	case ZSTD:
+		if w.zstd == nil {
+			level := zstd.SpeedDefault
+			if w.level > 0 {
+				level = zstd.EncoderLevelFromZstd(w.level)
+			}
+			if w.zstd, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(level), zstd.WithEncoderConcurrency(1)); err != nil {
+				return err
+			}
+		}
+		// EncodeAll appends to the header, it only reallocates when the block does not compress
+		w.zdata = w.zstd.EncodeAll(w.data[:w.pos], w.zdata[:headerSize])
+		compressedSize = len(w.zdata) - headerSize
+		w.zdata = w.zdata[:cap(w.zdata)]
+	default:
+		return fmt.Errorf("unsupported compression method: 0x%02x", byte(w.method))
 	}
 	compressedSize += compressHeaderSize
 	// fill the header, compressed_size_32 + uncompressed_size_32
-	w.zdata[16] = LZ4
+	w.zdata[16] = byte(w.method)
 	endian.PutUint32(w.zdata[17:], uint32(compressedSize))
 	endian.PutUint32(w.zdata[21:], uint32(w.pos))
 	// fill the checksum
@@ -85,6 +118,10 @@ func (w *Writer) Flush() (err error) {
 }
 
 func (w *Writer) Close() error {
+	if w.zstd != nil {
+		w.zstd.Close()
+		w.zstd = nil
+	}
 	w.data = nil
 	w.zdata = nil
 	return nil
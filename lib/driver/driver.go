@@ -20,7 +20,10 @@ package driver
 import (
 	"context"
 	"reflect"
+	"time"
 
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
 	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
 )
 
@@ -36,6 +39,68 @@ type (
 		MaxIdleConns int
 		Open         int
 		Idle         int
+		// WaitCount is the number of acquires that had to wait for a free connection
+		// and WaitDuration is the total time they waited.
+		WaitCount    int64
+		WaitDuration time.Duration
+		// MaxIdleClosed, MaxIdleTimeClosed and MaxLifetimeClosed count the connections closed
+		// because the idle pool was full, because of ConnMaxIdleTime and because of ConnMaxLifetime.
+		MaxIdleClosed     int64
+		MaxIdleTimeClosed int64
+		MaxLifetimeClosed int64
+		Dials             int64
+		DialErrors        int64
+		// AcquireTimeouts is the number of acquires that failed with ErrAcquireConnTimeout.
+		AcquireTimeouts int64
+		Hosts           []HostStats
+	}
+	// OperationStats are the measurements of a finished operation: query, exec, stream,
+	// batch.send, async_insert or ping.
+	OperationStats struct {
+		Operation string
+		QueryID   string
+		Duration  time.Duration
+		// Progress is the sum of the progress packets of the operation.
+		Progress proto.Progress
+		// Profile is the last profile info of the result, nil when the server sent none.
+		Profile *proto.ProfileInfo
+		// BatchRows is the number of rows of a batch.send.
+		BatchRows int
+		// Network is the traffic of the operation on its connection.
+		Network io.Counters
+		Err     error
+	}
+	// OperationInfo describes an operation when it starts, a dial and a handshake
+	// are operations too for an Instrumentation.
+	OperationInfo struct {
+		Operation string
+		QueryID   string
+		Query     string
+		Addr      string
+		Database  string
+	}
+	// ExecResult is what the server reported about an Exec or a batch Send in its progress
+	// and profile events packets.
+	ExecResult struct {
+		QueryID      string
+		WrittenRows  uint64
+		WrittenBytes uint64
+		Elapsed      time.Duration
+		// ProfileEvents are summed by name, the gauges keep their last value.
+		ProfileEvents map[string]int64
+	}
+	HostStats struct {
+		Addr    string
+		Healthy bool
+		// Open is the number of open connections to the host.
+		Open int
+		// Failures is the number of consecutive failed dials and health checks.
+		Failures  int
+		LastError error
+		// LastCheck is the time of the last health check, zero if health checks are disabled.
+		LastCheck time.Time
+		// EjectedUntil is the time the host is let back in after a failure.
+		EjectedUntil time.Time
 	}
 )
 
@@ -68,6 +133,22 @@ type (
 		Close() error
 		Err() error
 	}
+	// Stream is a subscription to an unbounded (streaming) query.
+	// Consume either Blocks or Rows, not both: they share the same source.
+	Stream interface {
+		Columns() []string
+		ColumnTypes() []ColumnType
+		Blocks() <-chan *proto.Block
+		Rows() <-chan StreamRow
+		Progress() proto.Progress
+		QueryID() string
+		Err() error
+		Close() error
+	}
+	StreamRow interface {
+		Scan(dest ...interface{}) error
+		ScanStruct(dest interface{}) error
+	}
 	Batch interface {
 		Abort() error
 		Append(v ...interface{}) error
@@ -75,6 +156,52 @@ type (
 		Column(int) BatchColumn
 		Send() error
 	}
+	// Streamer is implemented by the Conn of the native interface, Stream subscribes to an
+	// unbounded (streaming) query.
+	Streamer interface {
+		Stream(ctx context.Context, query string, args ...interface{}) (Stream, error)
+	}
+	// StreamDescriber is implemented by the Conn of the native interface. DescribeStream returns
+	// the kind and the columns of a stream or a view, name may be qualified with its database and
+	// its parts quoted with backquotes when they have a dot. ListStreams returns the streams and
+	// views of a database, without their columns, the current database when database is empty.
+	StreamDescriber interface {
+		DescribeStream(ctx context.Context, name string) (*StreamDescription, error)
+		ListStreams(ctx context.Context, database string) ([]StreamDescription, error)
+	}
+	// Tailer is implemented by the Conn of the native interface. Tail reads the rows of a stream
+	// since a time from its history, then keeps reading the new rows, in one feed. The feed ends
+	// with an error or when ctx is done.
+	Tailer interface {
+		Tail(ctx context.Context, stream string, since time.Time, opts TailOptions) (Rows, error)
+	}
+	// BlockReader is implemented by the Rows of the native interface. NextBlock returns the next
+	// whole block of the result set, or io.EOF. The block is owned by the driver: it is valid until
+	// the next call to Next, NextBlock or Close and must be copied to be retained.
+	BlockReader interface {
+		NextBlock() (*proto.Block, error)
+	}
+	// QueryIdentifier is implemented by the Rows and the batches of the native interface, QueryID
+	// is the ID the query was sent with, generated when it was not set with WithQueryID.
+	QueryIdentifier interface {
+		QueryID() string
+	}
+	// ResultExecer is implemented by the Conn of the native interface, ExecWithResult is Exec
+	// returning what the server reported about the query.
+	ResultExecer interface {
+		ExecWithResult(ctx context.Context, query string, args ...interface{}) (*ExecResult, error)
+	}
+	// ResultSender is implemented by the batches of the native interface, SendWithResult is
+	// Send returning what the server reported about the insert.
+	ResultSender interface {
+		SendWithResult() (*ExecResult, error)
+	}
+	// BlockAppender is implemented by the batches of the native interface for the adapters
+	// that append whole columns, such as lib/arrow. AppendBlock calls fn with the block of the
+	// batch, fn must append the same number of rows to every column.
+	BlockAppender interface {
+		AppendBlock(fn func(block *proto.Block) error) error
+	}
 	BatchColumn interface {
 		Append(interface{}) error
 	}
@@ -85,3 +212,55 @@ type (
 		DatabaseTypeName() string
 	}
 )
+
+// StreamKind is the kind of a stream, from its engine and its mode.
+type StreamKind string
+
+const (
+	StreamKindAppend           StreamKind = "append"
+	StreamKindVersionedKV      StreamKind = "versioned_kv"
+	StreamKindChangelogKV      StreamKind = "changelog_kv"
+	StreamKindChangelog        StreamKind = "changelog"
+	StreamKindExternal         StreamKind = "external"
+	StreamKindView             StreamKind = "view"
+	StreamKindMaterializedView StreamKind = "materialized_view"
+	// StreamKindOther is any other engine, see StreamDescription.Engine.
+	StreamKindOther StreamKind = "other"
+)
+
+// TailOptions configures Conn.Tail.
+type TailOptions struct {
+	// Columns are the columns of the feed, all the columns of the stream by default. The
+	// cursor columns are read as well, but they are only in the feed when Columns has them.
+	Columns []string
+	// Where filters the rows of both phases, Args are the values of its placeholders.
+	Where string
+	Args  []interface{}
+	// Cursor is the column the phases are stitched on, _tp_sn by default. _tp_sn is stitched
+	// per shard with _tp_shard. Another cursor must not decrease with the rows of a shard,
+	// the rows that tie on its last value in the history are not read twice.
+	Cursor string
+}
+
+type (
+	StreamDescription struct {
+		Database string
+		Name     string
+		Kind     StreamKind
+		Engine   string
+		Comment  string
+		Columns  []StreamColumn
+	}
+	StreamColumn struct {
+		Name string
+		Type column.Type
+		// Column is Type parsed by the column package, its Base, Columns, Key and Value
+		// methods walk the type tree. It is nil for the types the driver cannot read.
+		Column column.Interface
+		// DefaultKind is DEFAULT, MATERIALIZED, ALIAS or EPHEMERAL, empty without a default.
+		DefaultKind       string
+		DefaultExpression string
+		Codec             string
+		Comment           string
+	}
+)
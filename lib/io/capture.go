@@ -0,0 +1,192 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package io
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RecordKind is the kind of a capture record.
+type RecordKind uint8
+
+const (
+	RecordDial        RecordKind = iota + 1 // a new connection, the data is its address
+	RecordWireRead                          // bytes read from the connection, compressed
+	RecordWireWrite                         // bytes written to the connection, compressed
+	RecordDataRead                          // bytes read by the decoder, decompressed
+	RecordDataWrite                         // bytes written by the encoder, before compression
+	RecordPacketRead                        // the type of a packet of the server, recorded after its data record
+	RecordPacketWrite                       // the type of a packet of the client, recorded after its data record
+)
+
+func (k RecordKind) String() string {
+	switch k {
+	case RecordDial:
+		return "dial"
+	case RecordWireRead:
+		return "wire read"
+	case RecordWireWrite:
+		return "wire write"
+	case RecordDataRead:
+		return "data read"
+	case RecordDataWrite:
+		return "data write"
+	case RecordPacketRead:
+		return "packet read"
+	case RecordPacketWrite:
+		return "packet write"
+	}
+	return fmt.Sprintf("RecordKind(%d)", uint8(k))
+}
+
+// Record is an entry of a capture. Session numbers the connections of a capture from 1.
+type Record struct {
+	Session uint64
+	Time    time.Time
+	Kind    RecordKind
+	Data    []byte
+}
+
+const (
+	captureMagic  = "PROTONCAP\x01"
+	maxRecordSize = 1 << 31
+)
+
+// CaptureWriter writes the traffic of streams to w, a record per write call:
+// kind, session (uvarint), unix nanoseconds (varint), length of the data (uvarint), data.
+// It is safe for concurrent use, the first error stops the capture.
+type CaptureWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	sessions uint64
+	buf      []byte
+	err      error
+}
+
+func NewCaptureWriter(w io.Writer) *CaptureWriter {
+	c := CaptureWriter{w: w}
+	_, c.err = io.WriteString(w, captureMagic)
+	return &c
+}
+
+// Session starts the capture of a new connection to addr.
+func (c *CaptureWriter) Session(addr string) *CaptureSession {
+	c.mu.Lock()
+	c.sessions++
+	session := CaptureSession{id: c.sessions, w: c}
+	c.mu.Unlock()
+	session.record(RecordDial, []byte(addr))
+	return &session
+}
+
+// Err returns the error that stopped the capture.
+func (c *CaptureWriter) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *CaptureWriter) write(r Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	var varint [binary.MaxVarintLen64]byte
+	buf := append(c.buf[:0], byte(r.Kind))
+	buf = append(buf, varint[:binary.PutUvarint(varint[:], r.Session)]...)
+	buf = append(buf, varint[:binary.PutVarint(varint[:], r.Time.UnixNano())]...)
+	buf = append(buf, varint[:binary.PutUvarint(varint[:], uint64(len(r.Data)))]...)
+	buf = append(buf, r.Data...)
+	if _, c.err = c.w.Write(buf); len(buf) <= maxWriterSize {
+		c.buf = buf
+	}
+}
+
+// CaptureSession is the capture of a connection.
+type CaptureSession struct {
+	id uint64
+	w  *CaptureWriter
+}
+
+func (s *CaptureSession) record(kind RecordKind, p []byte) {
+	s.w.write(Record{
+		Session: s.id,
+		Time:    time.Now(),
+		Kind:    kind,
+		Data:    p,
+	})
+}
+
+// CaptureReader reads the records written by a CaptureWriter.
+type CaptureReader struct {
+	r *bufio.Reader
+}
+
+func NewCaptureReader(r io.Reader) (*CaptureReader, error) {
+	c := CaptureReader{
+		r: bufio.NewReader(r),
+	}
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(c.r, magic); err != nil || string(magic) != captureMagic {
+		return nil, errors.New("proton [capture]: not a capture")
+	}
+	return &c, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the capture.
+func (c *CaptureReader) Next() (Record, error) {
+	kind, err := c.r.ReadByte()
+	if err != nil {
+		return Record{}, err
+	}
+	record := Record{Kind: RecordKind(kind)}
+	if record.Session, err = binary.ReadUvarint(c.r); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	nano, err := binary.ReadVarint(c.r)
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	record.Time = time.Unix(0, nano)
+	size, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	if size > maxRecordSize {
+		return Record{}, fmt.Errorf("proton [capture]: record of %d bytes", size)
+	}
+	record.Data = make([]byte, size)
+	if _, err := io.ReadFull(c.r, record.Data); err != nil {
+		return Record{}, unexpectedEOF(err)
+	}
+	return record, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
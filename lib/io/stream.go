@@ -19,6 +19,8 @@ package io
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"io"
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/compress"
@@ -29,42 +31,151 @@ const (
 	maxWriterSize = 1 << 20
 )
 
+// NewStream returns a stream whose compressed writes use LZ4, compressed reads accept any method.
 func NewStream(rw io.ReadWriter) *Stream {
-	stream := Stream{
-		r: bufio.NewReaderSize(rw, maxReaderSize),
-		w: bufio.NewWriterSize(rw, maxWriterSize),
-	}
+	return NewStreamLevel(rw, compress.LZ4, 0)
+}
+
+// NewStreamLevel returns a stream whose compressed writes use the given method and level,
+// compressed reads accept any method.
+func NewStreamLevel(rw io.ReadWriter, method compress.Method, level int) *Stream {
+	var stream Stream
+	stream.r = bufio.NewReaderSize(&wire{r: rw, n: &stream.counters.WireRead, stream: &stream, kind: RecordWireRead}, maxReaderSize)
+	stream.w = bufio.NewWriterSize(&wire{w: rw, n: &stream.counters.WireWritten, stream: &stream, kind: RecordWireWrite}, maxWriterSize)
 	stream.compress.r = compress.NewReader(stream.r)
-	stream.compress.w = compress.NewWriter(stream.w)
+	stream.compress.w = compress.NewWriterLevel(stream.w, method, level)
 	return &stream
 }
 
+// Counters are the bytes that went through a stream. Wire bytes are read from and written
+// to the connection, data bytes are the same traffic before compression.
+type Counters struct {
+	WireRead    uint64
+	WireWritten uint64
+	DataRead    uint64
+	DataWritten uint64
+}
+
 type Stream struct {
 	r        *bufio.Reader
 	w        *bufio.Writer
+	counters Counters
+	capture  *CaptureSession
+	// secrets are the ranges of the wire writes the capture masks, see WriteSecret.
+	secrets  [][2]uint64
 	compress struct {
-		enable bool
-		r      *compress.Reader
-		w      *compress.Writer
+		read  bool
+		write bool
+		r     *compress.Reader
+		w     *compress.Writer
 	}
 }
 
-func (s *Stream) Compress(v bool) {
-	s.compress.enable = v
+// CompressRead and CompressWrite toggle each direction separately,
+// so a cancel packet can be written while a compressed block is being read.
+func (s *Stream) CompressRead(v bool) {
+	s.compress.read = v
+}
+
+func (s *Stream) CompressWrite(v bool) {
+	s.compress.write = v
+}
+
+// SetCompression changes the method and the level of the compressed writes, it must not
+// be called while a compressed block is being written.
+func (s *Stream) SetCompression(method compress.Method, level int) {
+	s.compress.w = compress.NewWriterLevel(s.w, method, level)
 }
 
-func (s *Stream) Read(p []byte) (int, error) {
-	if s.compress.enable {
-		return io.ReadFull(s.compress.r, p)
+// Capture records the traffic of the stream to session from now on, see CaptureWriter.
+func (s *Stream) Capture(session *CaptureSession) {
+	s.capture = session
+}
+
+// WriteSecret writes p like Write, such as the password of the hello, but the capture records
+// it masked. The writes must not be compressed.
+func (s *Stream) WriteSecret(p []byte) (int, error) {
+	if s.compress.write {
+		return 0, errors.New("secret written to a compressed stream")
+	}
+	if s.capture != nil && len(p) != 0 {
+		start := s.counters.WireWritten + uint64(s.w.Buffered())
+		s.secrets = append(s.secrets, [2]uint64{start, start + uint64(len(p))})
+	}
+	n, err := s.w.Write(p)
+	s.counters.DataWritten += uint64(n)
+	s.record(RecordDataWrite, bytes.Repeat([]byte{'*'}, n))
+	return n, err
+}
+
+// mask returns the wire write p that starts at offset with the secrets in it masked.
+func (s *Stream) mask(offset uint64, p []byte) []byte {
+	var (
+		end    = offset + uint64(len(p))
+		masked = p
+	)
+	for len(s.secrets) != 0 && s.secrets[0][0] < end {
+		start, stop := s.secrets[0][0], s.secrets[0][1]
+		if start < offset {
+			start = offset
+		}
+		if stop > end {
+			stop = end
+		}
+		if &masked[0] == &p[0] {
+			masked = append([]byte(nil), p...)
+		}
+		for i := start; i < stop; i++ {
+			masked[i-offset] = '*'
+		}
+		if s.secrets[0][1] > end {
+			break
+		}
+		s.secrets = s.secrets[1:]
 	}
-	return io.ReadFull(s.r, p)
+	return masked
 }
 
-func (s *Stream) Write(p []byte) (int, error) {
-	if s.compress.enable {
-		return s.compress.w.Write(p)
+// Mark records the type of a packet that was just read or written, kind is
+// RecordPacketRead or RecordPacketWrite.
+func (s *Stream) Mark(kind RecordKind, packet byte) {
+	if s.capture != nil {
+		s.capture.record(kind, []byte{packet})
 	}
-	return s.w.Write(p)
+}
+
+// Counters must not be called concurrently with Read and Write.
+func (s *Stream) Counters() Counters {
+	return s.counters
+}
+
+func (s *Stream) Read(p []byte) (n int, err error) {
+	if s.compress.read {
+		n, err = io.ReadFull(s.compress.r, p)
+	} else {
+		n, err = io.ReadFull(s.r, p)
+	}
+	s.counters.DataRead += uint64(n)
+	s.record(RecordDataRead, p[:n])
+	return n, err
+}
+
+func (s *Stream) Write(p []byte) (n int, err error) {
+	if s.compress.write {
+		n, err = s.compress.w.Write(p)
+	} else {
+		n, err = s.w.Write(p)
+	}
+	s.counters.DataWritten += uint64(n)
+	s.record(RecordDataWrite, p[:n])
+	return n, err
+}
+
+func (s *Stream) record(kind RecordKind, p []byte) {
+	if s.capture == nil || len(p) == 0 {
+		return
+	}
+	s.capture.record(kind, p)
 }
 
 func (s *Stream) Flush() error {
@@ -80,3 +191,30 @@ func (s *Stream) Close() error {
 	s.compress.w.Close()
 	return nil
 }
+
+// wire counts and captures the bytes read from or written to the connection.
+type wire struct {
+	r      io.Reader
+	w      io.Writer
+	n      *uint64
+	stream *Stream
+	kind   RecordKind
+}
+
+func (w *wire) Read(p []byte) (int, error) {
+	n, err := w.r.Read(p)
+	*w.n += uint64(n)
+	w.stream.record(w.kind, p[:n])
+	return n, err
+}
+
+func (w *wire) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if len(w.stream.secrets) != 0 && n != 0 {
+		w.stream.record(w.kind, w.stream.mask(*w.n, p[:n]))
+	} else {
+		w.stream.record(w.kind, p[:n])
+	}
+	*w.n += uint64(n)
+	return n, err
+}
@@ -0,0 +1,255 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package lexer splits Proton SQL into tokens, so that placeholders, keywords and the ends
+// of statements are only looked for outside of literals, quoted identifiers and comments.
+package lexer
+
+import "strings"
+
+type Kind uint8
+
+const (
+	Space              Kind = iota
+	Comment                 // -- to the end of the line, or /* */ which nest
+	String                  // '...' or a heredoc $tag$...$tag$
+	QuotedIdentifier        // `...` or "..."
+	Word                    // a keyword or an identifier
+	Number                  // 42, 1.5e-3, 0x1F
+	NumericPlaceholder      // $1
+	NamedPlaceholder        // @name
+	Parameter               // {name:Type}
+	Punct                   // any other byte
+)
+
+type Token struct {
+	Kind Kind
+	Text string
+	// Pos is the byte offset of the token in the query.
+	Pos int
+}
+
+// Tokenize never fails: an unterminated literal or comment runs to the end of the query,
+// and the texts of the tokens put together are the query.
+func Tokenize(query string) []Token {
+	var tokens []Token
+	for pos := 0; pos < len(query); {
+		kind, end := next(query, pos)
+		tokens = append(tokens, Token{
+			Kind: kind,
+			Text: query[pos:end],
+			Pos:  pos,
+		})
+		pos = end
+	}
+	return tokens
+}
+
+// Split returns the statements of a query separated by semicolons, without the semicolons
+// and the spaces around them. Statements that only have comments are left out.
+func Split(query string) []string {
+	var (
+		statements []string
+		start      int
+		empty      = true
+	)
+	for _, t := range Tokenize(query) {
+		switch {
+		case t.Kind == Punct && t.Text == ";":
+			if !empty {
+				statements = append(statements, strings.TrimSpace(query[start:t.Pos]))
+			}
+			start, empty = t.Pos+1, true
+		case t.Kind != Space && t.Kind != Comment:
+			empty = false
+		}
+	}
+	if !empty {
+		statements = append(statements, strings.TrimSpace(query[start:]))
+	}
+	return statements
+}
+
+func next(query string, pos int) (Kind, int) {
+	c := query[pos]
+	switch {
+	case isSpace(c):
+		end := pos + 1
+		for end < len(query) && isSpace(query[end]) {
+			end++
+		}
+		return Space, end
+	case c == '-' && strings.HasPrefix(query[pos:], "--"):
+		if end := strings.IndexByte(query[pos:], '\n'); end != -1 {
+			return Comment, pos + end
+		}
+		return Comment, len(query)
+	case c == '/' && strings.HasPrefix(query[pos:], "/*"):
+		return Comment, blockComment(query, pos)
+	case c == '\'':
+		return String, quoted(query, pos)
+	case c == '`' || c == '"':
+		return QuotedIdentifier, quoted(query, pos)
+	case isDigit(c):
+		return Number, number(query, pos)
+	case isWordStart(c):
+		end := pos + 1
+		for end < len(query) && isWord(query[end]) {
+			end++
+		}
+		return Word, end
+	case c == '$':
+		end := pos + 1
+		for end < len(query) && isDigit(query[end]) {
+			end++
+		}
+		if end > pos+1 {
+			return NumericPlaceholder, end
+		}
+		if end, ok := heredoc(query, pos); ok {
+			return String, end
+		}
+	case c == '@':
+		end := pos + 1
+		for end < len(query) && isWord(query[end]) {
+			end++
+		}
+		if end > pos+1 {
+			return NamedPlaceholder, end
+		}
+	case c == '{':
+		if end, ok := parameter(query, pos); ok {
+			return Parameter, end
+		}
+	}
+	return Punct, pos + 1
+}
+
+// quoted returns the end of the literal at pos, its quote is escaped with a backslash or doubled.
+func quoted(query string, pos int) int {
+	quote := query[pos]
+	for i := pos + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			i++
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func blockComment(query string, pos int) int {
+	depth := 0
+	for i := pos; i < len(query)-1; i++ {
+		switch query[i : i+2] {
+		case "/*":
+			depth++
+			i++
+		case "*/":
+			if depth--; depth == 0 {
+				return i + 2
+			}
+			i++
+		}
+	}
+	return len(query)
+}
+
+func number(query string, pos int) int {
+	end := pos + 1
+	for end < len(query) {
+		switch c := query[end]; {
+		case isWord(c) || c == '.':
+		case (c == '+' || c == '-') && (query[end-1] == 'e' || query[end-1] == 'E') &&
+			!strings.HasPrefix(query[pos:], "0x") && !strings.HasPrefix(query[pos:], "0X"):
+		default:
+			return end
+		}
+		end++
+	}
+	return end
+}
+
+// heredoc returns the end of a $tag$...$tag$ string at pos.
+func heredoc(query string, pos int) (int, bool) {
+	end := pos + 1
+	for end < len(query) && isWord(query[end]) {
+		end++
+	}
+	if end == len(query) || query[end] != '$' {
+		return 0, false
+	}
+	tag := query[pos : end+1]
+	if i := strings.Index(query[end+1:], tag); i != -1 {
+		return end + 1 + i + len(tag), true
+	}
+	return len(query), true
+}
+
+// parameter returns the end of a {name:Type} parameter at pos, the type may have quoted arguments.
+func parameter(query string, pos int) (int, bool) {
+	i := pos + 1
+	for i < len(query) && isSpace(query[i]) {
+		i++
+	}
+	start := i
+	for i < len(query) && isWord(query[i]) {
+		i++
+	}
+	for i < len(query) && isSpace(query[i]) {
+		i++
+	}
+	if i == start || i == len(query) || query[i] != ':' {
+		return 0, false
+	}
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\'', '"', '`':
+			i = quoted(query, i) - 1
+		case '}':
+			return i + 1, true
+		case '{', ';':
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f', '\v':
+		return true
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isWord(c byte) bool {
+	return isWordStart(c) || isDigit(c)
+}
@@ -0,0 +1,115 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func kinds(query string) map[string]Kind {
+	tokens := make(map[string]Kind)
+	for _, t := range Tokenize(query) {
+		if t.Kind != Space {
+			tokens[t.Text] = t.Kind
+		}
+	}
+	return tokens
+}
+
+func TestTokenize(t *testing.T) {
+	query := "SELECT $1, @name, {p:datetime64(3, 'UTC')}, 'it''s $2', `col @x`, \"q\\\"@y\", 1.5e-3 -- $3\n/* @z /* nested */ $4 */ FROM t WHERE s @> 0x1F"
+	var text strings.Builder
+	for _, token := range Tokenize(query) {
+		assert.Equal(t, token.Text, query[token.Pos:token.Pos+len(token.Text)])
+		text.WriteString(token.Text)
+	}
+	assert.Equal(t, query, text.String())
+	assert.Equal(t, map[string]Kind{
+		"SELECT":                   Word,
+		"$1":                       NumericPlaceholder,
+		",":                        Punct,
+		"@name":                    NamedPlaceholder,
+		"{p:datetime64(3, 'UTC')}": Parameter,
+		"'it''s $2'":               String,
+		"`col @x`":                 QuotedIdentifier,
+		"\"q\\\"@y\"":              QuotedIdentifier,
+		"1.5e-3":                   Number,
+		"-- $3":                    Comment,
+		"/* @z /* nested */ $4 */": Comment,
+		"FROM":                     Word,
+		"t":                        Word,
+		"WHERE":                    Word,
+		"s":                        Word,
+		"@":                        Punct,
+		">":                        Punct,
+		"0x1F":                     Number,
+	}, kinds(query))
+}
+
+func TestTokenizeEdgeCases(t *testing.T) {
+	assert.Equal(t, map[string]Kind{
+		"SELECT":              Word,
+		"$tag$ it's $1 $tag$": String,
+		"$$ @x $$":            String,
+		",":                   Punct,
+	}, kinds("SELECT $tag$ it's $1 $tag$, $$ @x $$"))
+	assert.Equal(t, map[string]Kind{
+		"map": Word,
+		"(":   Punct,
+		"{":   Punct,
+		"'a'": String,
+		":":   Punct,
+		"1":   Number,
+		"}":   Punct,
+		")":   Punct,
+	}, kinds("map({'a': 1})"))
+	for _, query := range []string{"SELECT 'unterminated", "SELECT /* unterminated", "SELECT {p:string"} {
+		tokens := Tokenize(query)
+		assert.Equal(t, len(query), tokens[len(tokens)-1].Pos+len(tokens[len(tokens)-1].Text))
+	}
+}
+
+func TestSplit(t *testing.T) {
+	assert.Equal(t, []string{
+		"CREATE STREAM t (s string DEFAULT ';')",
+		"INSERT INTO t (s) VALUES ('a;b') -- ;",
+		"SELECT `;` FROM t",
+	}, Split(`
+		CREATE STREAM t (s string DEFAULT ';');
+		INSERT INTO t (s) VALUES ('a;b') -- ;
+		;; /* only a comment */ ;
+		SELECT `+"`;`"+` FROM t;
+	`))
+	assert.Empty(t, Split(" ; -- nothing"))
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `'it\'s a \\ test'`, QuoteString(`it's a \ test`))
+	assert.Equal(t, "`we\\`ird\\\\`", QuoteIdentifier("we`ird\\"))
+	for _, s := range []string{"", "plain", `it's`, `a\`, `\'`} {
+		assert.Equal(t, s, UnquoteString(QuoteString(s)))
+	}
+	assert.Equal(t, "not quoted", UnquoteString("not quoted"))
+	for _, name := range []string{"a.b", "we`ird", `a\`} {
+		assert.Equal(t, name, UnquoteIdentifier(QuoteIdentifier(name)))
+	}
+	assert.Equal(t, `a"b`, UnquoteIdentifier(`"a""b"`))
+}
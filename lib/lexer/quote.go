@@ -0,0 +1,55 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package lexer
+
+import "strings"
+
+var (
+	stringReplacer     = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	identifierReplacer = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	unquoteReplacer    = strings.NewReplacer(`\\`, `\`, `\'`, `'`)
+	unquoteIdentifier  = strings.NewReplacer(`\\`, `\`, "\\`", "`", `\"`, `"`, "``", "`", `""`, `"`)
+)
+
+// QuoteString returns s as a string literal, its backslashes and quotes escaped.
+func QuoteString(s string) string {
+	return "'" + stringReplacer.Replace(s) + "'"
+}
+
+// QuoteIdentifier returns name as a quoted identifier, its backslashes and backquotes escaped.
+func QuoteIdentifier(name string) string {
+	return "`" + identifierReplacer.Replace(name) + "`"
+}
+
+// UnquoteString returns the value of a string literal written by QuoteString, s is returned
+// as it is when it is not quoted.
+func UnquoteString(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return unquoteReplacer.Replace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// UnquoteIdentifier returns the name of an identifier quoted with backquotes or double quotes,
+// s is returned as it is when it is not quoted.
+func UnquoteIdentifier(s string) string {
+	if len(s) >= 2 && (s[0] == '`' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return unquoteIdentifier.Replace(s[1 : len(s)-1])
+	}
+	return s
+}
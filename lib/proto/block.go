@@ -67,6 +67,15 @@ func (b *Block) Append(v ...interface{}) (err error) {
 	return nil
 }
 
+// Head returns a block with the first n columns of b, it shares their data.
+func (b *Block) Head(n int) *Block {
+	return &Block{
+		names:   b.names[:n],
+		Packet:  b.Packet,
+		Columns: b.Columns[:n],
+	}
+}
+
 func (b *Block) ColumnsNames() []string {
 	return b.names
 }
@@ -102,6 +111,11 @@ func (b *Block) Encode(encoder *binary.Encoder, revision uint64) error {
 		if err := encoder.String(string(c.Type())); err != nil {
 			return err
 		}
+		if revision >= DBMS_MIN_REVISION_WITH_CUSTOM_SERIALIZATION {
+			if err := encoder.Bool(false); err != nil { // has_custom
+				return err
+			}
+		}
 		if serialize, ok := c.(column.CustomSerialization); ok {
 			if err := serialize.WriteStatePrefix(encoder); err != nil {
 				return &BlockError{
@@ -156,6 +170,19 @@ func (b *Block) Decode(decoder *binary.Decoder, revision uint64) (err error) {
 		if columnType, err = decoder.String(); err != nil {
 			return err
 		}
+		if revision >= DBMS_MIN_REVISION_WITH_CUSTOM_SERIALIZATION {
+			hasCustom, err := decoder.Bool()
+			if err != nil {
+				return err
+			}
+			if hasCustom {
+				return &BlockError{
+					Op:         "Decode",
+					Err:        errors.New("custom serialization is not supported"),
+					ColumnName: columnName,
+				}
+			}
+		}
 		c, err := column.Type(columnType).Column()
 		if err != nil {
 			return err
@@ -237,3 +264,7 @@ func (e *BlockError) Error() string {
 	}
 	return fmt.Sprintf("proton [%s]: %s %s", e.Op, e.ColumnName, e.Err)
 }
+
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,92 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"go/format"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed error_codes.tpl
+	errorCodesSrc string
+	//go:embed error_codes.csv
+	errorCodesCSV string
+)
+
+type errorCode struct {
+	Code      int32
+	Name      string
+	Category  string
+	Retryable bool
+}
+
+func readErrorCodes() ([]errorCode, error) {
+	records, err := csv.NewReader(strings.NewReader(errorCodesCSV)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]errorCode, 0, len(records))
+	for _, record := range records[1:] {
+		code, err := strconv.ParseInt(record[0], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		retryable, err := strconv.ParseBool(record[3])
+		if err != nil {
+			return nil, err
+		}
+		var category strings.Builder
+		category.WriteString("Category")
+		for _, part := range strings.Split(record[2], "_") {
+			category.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+		codes = append(codes, errorCode{
+			Code:      int32(code),
+			Name:      record[1],
+			Category:  category.String(),
+			Retryable: retryable,
+		})
+	}
+	return codes, nil
+}
+
+func main() {
+	codes, err := readErrorCodes()
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := template.Must(template.New("error_codes").Parse(errorCodesSrc)).Execute(out, codes); err != nil {
+		log.Fatal(err)
+	}
+	data, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("error_codes_gen.go", data, 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -32,6 +32,10 @@ const (
 	DBMS_MIN_PROTOCOL_VERSION_WITH_INITIAL_QUERY_START_TIME   = 54449
 	DBMS_MIN_PROTOCOL_VERSION_WITH_INCREMENTAL_PROFILE_EVENTS = 54451
 	DBMS_MIN_REVISION_WITH_PARALLEL_REPLICAS                  = 54453
+	DBMS_MIN_REVISION_WITH_CUSTOM_SERIALIZATION               = 54454
+	DBMS_MIN_PROTOCOL_VERSION_WITH_PROFILE_EVENTS_IN_INSERT   = 54456
+	DBMS_MIN_PROTOCOL_VERSION_WITH_ADDENDUM                   = 54458
+	DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS                 = 54459
 	DBMS_TCP_PROTOCOL_VERSION                                 = DBMS_MIN_REVISION_WITH_PARALLEL_REPLICAS
 )
 
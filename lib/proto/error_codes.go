@@ -0,0 +1,124 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package proto
+
+import "errors"
+
+// ErrorCategory classifies the exception codes of the server, see codegen/error_codes.csv.
+type ErrorCategory int
+
+const (
+	CategoryOther ErrorCategory = iota
+	CategoryNetwork
+	CategoryTimeout
+	CategoryOverloaded
+	CategoryAuth
+	CategorySyntax
+	CategoryQuery
+	CategorySchema
+	CategoryTableNotFound
+	CategoryDatabaseNotFound
+	CategoryCanceled
+)
+
+func (c ErrorCategory) String() string {
+	switch c {
+	case CategoryNetwork:
+		return "network"
+	case CategoryTimeout:
+		return "timeout"
+	case CategoryOverloaded:
+		return "overloaded"
+	case CategoryAuth:
+		return "auth"
+	case CategorySyntax:
+		return "syntax"
+	case CategoryQuery:
+		return "query"
+	case CategorySchema:
+		return "schema"
+	case CategoryTableNotFound:
+		return "table_not_found"
+	case CategoryDatabaseNotFound:
+		return "database_not_found"
+	case CategoryCanceled:
+		return "canceled"
+	}
+	return "other"
+}
+
+// The sentinel errors matched by errors.Is on an Exception of the category.
+var (
+	ErrRetryable     = errors.New("proton: retryable error")
+	ErrTimeout       = errors.New("proton: timeout")
+	ErrAuthFailure   = errors.New("proton: authentication failure")
+	ErrSyntax        = errors.New("proton: syntax error")
+	ErrTableNotFound = errors.New("proton: table not found")
+)
+
+type errorCode struct {
+	Name      string
+	Category  ErrorCategory
+	Retryable bool
+}
+
+// CodeName is the name of the exception code, like UNKNOWN_STREAM, or "" for an unknown code.
+func (e *Exception) CodeName() string {
+	return errorCodes[e.Code].Name
+}
+
+func (e *Exception) Category() ErrorCategory {
+	return errorCodes[e.Code].Category
+}
+
+// Retryable reports whether the same query may succeed when it is sent again.
+func (e *Exception) Retryable() bool {
+	return errorCodes[e.Code].Retryable
+}
+
+// Is matches the sentinel errors of the category of e. Only the code of the outer exception is
+// classified, a retryable nested exception does not make the query retryable.
+func (e *Exception) Is(target error) bool {
+	if e.cause {
+		return false
+	}
+	switch target {
+	case ErrRetryable:
+		return e.Retryable()
+	case ErrTimeout:
+		return e.Category() == CategoryTimeout
+	case ErrAuthFailure:
+		return e.Category() == CategoryAuth
+	case ErrSyntax:
+		return e.Category() == CategorySyntax
+	case ErrTableNotFound:
+		return e.Category() == CategoryTableNotFound
+	}
+	return false
+}
+
+// Unwrap returns the first nested exception, the cause of e, with the rest of Nested.
+func (e *Exception) Unwrap() error {
+	if len(e.Nested) == 0 {
+		return nil
+	}
+	cause := e.Nested[0]
+	cause.Nested = e.Nested[1:]
+	cause.cause = true
+	return &cause
+}
@@ -30,13 +30,40 @@ type Exception struct {
 	Message    string
 	StackTrace string
 	Nested     []Exception
-	nested     bool
+	// QueryID is the ID of the query that failed, set by the driver.
+	QueryID string
+	nested  bool
+	// cause is set on the exceptions returned by Unwrap, they are not classified by Is.
+	cause bool
 }
 
 func (e *Exception) Error() string {
 	return fmt.Sprintf("code: %d, message: %s", e.Code, e.Message)
 }
 
+// Encode writes e and its nested exceptions, as a server does.
+func (e *Exception) Encode(encoder *binary.Encoder) error {
+	exceptions := append([]Exception{*e}, e.Nested...)
+	for i, ex := range exceptions {
+		if err := encoder.Int32(ex.Code); err != nil {
+			return err
+		}
+		if err := encoder.String(ex.Name); err != nil {
+			return err
+		}
+		if err := encoder.String(ex.Message); err != nil {
+			return err
+		}
+		if err := encoder.String(ex.StackTrace); err != nil {
+			return err
+		}
+		if err := encoder.Bool(i != len(exceptions)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (e *Exception) Decode(decoder *binary.Decoder) (err error) {
 	var exceptions []Exception
 	for {
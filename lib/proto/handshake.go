@@ -33,9 +33,19 @@ const (
 	ClientTCPProtocolVersion = DBMS_TCP_PROTOCOL_VERSION
 )
 
-type ClientHandshake struct{}
+// ClientHandshake is the hello of the client, ProtocolVersion is ClientTCPProtocolVersion when zero.
+type ClientHandshake struct {
+	ProtocolVersion uint64
+}
+
+func (h ClientHandshake) protocolVersion() uint64 {
+	if h.ProtocolVersion == 0 {
+		return ClientTCPProtocolVersion
+	}
+	return h.ProtocolVersion
+}
 
-func (ClientHandshake) Encode(encoder *binary.Encoder) error {
+func (h ClientHandshake) Encode(encoder *binary.Encoder) error {
 	if err := encoder.String(ClientName); err != nil {
 		return err
 	}
@@ -45,11 +55,11 @@ func (ClientHandshake) Encode(encoder *binary.Encoder) error {
 	if err := encoder.Uvarint(ClientVersionMinor); err != nil {
 		return err
 	}
-	return encoder.Uvarint(ClientTCPProtocolVersion)
+	return encoder.Uvarint(h.protocolVersion())
 }
 
-func (ClientHandshake) String() string {
-	return fmt.Sprintf("%s %d.%d.%d", ClientName, ClientVersionMajor, ClientVersionMinor, ClientTCPProtocolVersion)
+func (h ClientHandshake) String() string {
+	return fmt.Sprintf("%s %d.%d.%d", ClientName, ClientVersionMajor, ClientVersionMinor, h.protocolVersion())
 }
 
 type ServerHandshake struct {
@@ -101,6 +111,40 @@ func (srv *ServerHandshake) Decode(decoder *binary.Decoder) (err error) {
 	return nil
 }
 
+// Encode writes the hello of a server, the fields depend on srv.Revision.
+func (srv *ServerHandshake) Encode(encoder *binary.Encoder) error {
+	if err := encoder.String(srv.Name); err != nil {
+		return err
+	}
+	if err := encoder.Uvarint(srv.Version.Major); err != nil {
+		return err
+	}
+	if err := encoder.Uvarint(srv.Version.Minor); err != nil {
+		return err
+	}
+	if err := encoder.Uvarint(srv.Revision); err != nil {
+		return err
+	}
+	if srv.Revision >= DBMS_MIN_REVISION_WITH_SERVER_TIMEZONE {
+		name := "UTC"
+		if srv.Timezone != nil {
+			name = srv.Timezone.String()
+		}
+		if err := encoder.String(name); err != nil {
+			return err
+		}
+	}
+	if srv.Revision >= DBMS_MIN_REVISION_WITH_SERVER_DISPLAY_NAME {
+		if err := encoder.String(srv.DisplayName); err != nil {
+			return err
+		}
+	}
+	if srv.Revision >= DBMS_MIN_REVISION_WITH_VERSION_PATCH {
+		return encoder.Uvarint(srv.Version.Patch)
+	}
+	return nil
+}
+
 func (srv ServerHandshake) String() string {
 	return fmt.Sprintf("%s (%s) server version %d.%d.%d revision %d (timezone %s)", srv.Name, srv.DisplayName,
 		srv.Version.Major,
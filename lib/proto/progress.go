@@ -54,6 +54,21 @@ func (p *Progress) Decode(decoder *binary.Decoder, revision uint64) (err error)
 	return nil
 }
 
+func (p *Progress) Encode(encoder *binary.Encoder, revision uint64) error {
+	for _, v := range []uint64{p.Rows, p.Bytes, p.TotalRows} {
+		if err := encoder.Uvarint(v); err != nil {
+			return err
+		}
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_CLIENT_WRITE_INFO {
+		if err := encoder.Uvarint(p.WroteRows); err != nil {
+			return err
+		}
+		return encoder.Uvarint(p.WroteBytes)
+	}
+	return nil
+}
+
 func (p *Progress) String() string {
 	if !p.withClient {
 		return fmt.Sprintf("rows=%d, bytes=%d, total rows=%d", p.Rows, p.Bytes, p.TotalRows)
@@ -20,8 +20,10 @@ package proto
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -36,6 +38,7 @@ type Query struct {
 	Body           string
 	QuotaKey       string
 	Settings       Settings
+	Parameters     Parameters
 	Compression    bool
 	InitialUser    string
 	InitialAddress string
@@ -62,7 +65,19 @@ func (q *Query) Encode(encoder *binary.Encoder, revision uint64) error {
 		encoder.Byte(StateComplete)
 		encoder.Bool(q.Compression)
 	}
-	return encoder.String(q.Body)
+	if err := encoder.String(q.Body); err != nil {
+		return err
+	}
+	if revision >= DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS {
+		if err := q.Parameters.Encode(encoder); err != nil {
+			return err
+		}
+		return encoder.String("" /* end of parameters */)
+	}
+	if len(q.Parameters) != 0 {
+		return fmt.Errorf("query parameters require protocol revision %d, the server has %d", DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS, revision)
+	}
+	return nil
 }
 
 func (q *Query) encodeClientInfo(encoder *binary.Encoder, revision uint64) error {
@@ -118,6 +133,177 @@ func (q *Query) encodeClientInfo(encoder *binary.Encoder, revision uint64) error
 	return nil
 }
 
+// Decode reads a query as a server does. Of the client info, only the initial user and address,
+// the quota key and the span are kept.
+func (q *Query) Decode(decoder *binary.Decoder, revision uint64) (err error) {
+	if q.ID, err = decoder.String(); err != nil {
+		return err
+	}
+	if err := q.decodeClientInfo(decoder, revision); err != nil {
+		return err
+	}
+	if err := q.Settings.Decode(decoder, revision); err != nil {
+		return err
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_INTERSERVER_SECRET {
+		if _, err := decoder.String(); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Uvarint(); err != nil { // stage
+		return err
+	}
+	if q.Compression, err = decoder.Bool(); err != nil {
+		return err
+	}
+	if q.Body, err = decoder.String(); err != nil {
+		return err
+	}
+	if revision >= DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS {
+		return q.Parameters.Decode(decoder)
+	}
+	return nil
+}
+
+func (q *Query) decodeClientInfo(decoder *binary.Decoder, revision uint64) (err error) {
+	kind, err := decoder.ReadByte()
+	if err != nil || kind == ClientQueryNone {
+		return err
+	}
+	read := func(dest ...*string) error {
+		for _, d := range dest {
+			v, err := decoder.String()
+			if err != nil {
+				return err
+			}
+			if d != nil {
+				*d = v
+			}
+		}
+		return nil
+	}
+	skipUvarints := func(n int) error {
+		for i := 0; i < n; i++ {
+			if _, err := decoder.Uvarint(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := read(&q.InitialUser, nil /* initial_query_id */, &q.InitialAddress); err != nil {
+		return err
+	}
+	if revision >= DBMS_MIN_PROTOCOL_VERSION_WITH_INITIAL_QUERY_START_TIME {
+		if _, err := decoder.Int64(); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.ReadByte(); err != nil { // interface
+		return err
+	}
+	if err := read(nil /* os_user */, nil /* hostname */, nil /* client_name */); err != nil {
+		return err
+	}
+	if err := skipUvarints(3 /* client version */); err != nil {
+		return err
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_QUOTA_KEY_IN_CLIENT_INFO {
+		if err := read(&q.QuotaKey); err != nil {
+			return err
+		}
+	}
+	if revision >= DBMS_MIN_PROTOCOL_VERSION_WITH_DISTRIBUTED_DEPTH {
+		if err := skipUvarints(1); err != nil {
+			return err
+		}
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_VERSION_PATCH {
+		if err := skipUvarints(1); err != nil {
+			return err
+		}
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_OPENTELEMETRY {
+		hasSpan, err := decoder.ReadByte()
+		if err != nil {
+			return err
+		}
+		if hasSpan == 1 {
+			var (
+				config trace.SpanContextConfig
+				state  string
+			)
+			if err := decoder.Raw(config.TraceID[:]); err != nil {
+				return err
+			}
+			if err := decoder.Raw(config.SpanID[:]); err != nil {
+				return err
+			}
+			if err := read(&state); err != nil {
+				return err
+			}
+			flags, err := decoder.ReadByte()
+			if err != nil {
+				return err
+			}
+			config.TraceFlags = trace.TraceFlags(flags)
+			if config.TraceState, err = trace.ParseTraceState(state); err != nil {
+				return err
+			}
+			q.Span = trace.NewSpanContext(config)
+		}
+	}
+	if revision >= DBMS_MIN_REVISION_WITH_PARALLEL_REPLICAS {
+		return skipUvarints(3)
+	}
+	return nil
+}
+
+// Parameters are the values of the {name:Type} placeholders of a query, in the text format of their type.
+type Parameters map[string]string
+
+const settingFlagCustom = 0x02
+
+func (p *Parameters) Decode(decoder *binary.Decoder) error {
+	for {
+		name, err := decoder.String()
+		if err != nil || len(name) == 0 {
+			return err
+		}
+		if _, err := decoder.Uvarint(); err != nil { // flags
+			return err
+		}
+		value, err := decoder.String()
+		if err != nil {
+			return err
+		}
+		if *p == nil {
+			*p = make(Parameters)
+		}
+		(*p)[name] = lexer.UnquoteString(value)
+	}
+}
+
+func (p Parameters) Encode(encoder *binary.Encoder) error {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := encoder.String(name); err != nil {
+			return err
+		}
+		if err := encoder.Uvarint(settingFlagCustom); err != nil {
+			return err
+		}
+		// the value is sent as a quoted string field
+		if err := encoder.String(lexer.QuoteString(p[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type Settings []Setting
 
 type Setting struct {
@@ -134,6 +320,31 @@ func (s Settings) Encode(encoder *binary.Encoder, revision uint64) error {
 	return nil
 }
 
+// Decode reads the settings up to the empty name that ends them, the values are strings,
+// or uint64 before DBMS_MIN_REVISION_WITH_SETTINGS_SERIALIZED_AS_STRINGS.
+func (s *Settings) Decode(decoder *binary.Decoder, revision uint64) error {
+	for {
+		key, err := decoder.String()
+		if err != nil || len(key) == 0 {
+			return err
+		}
+		setting := Setting{Key: key}
+		if revision <= DBMS_MIN_REVISION_WITH_SETTINGS_SERIALIZED_AS_STRINGS {
+			if setting.Value, err = decoder.Uvarint(); err != nil {
+				return err
+			}
+		} else {
+			if _, err := decoder.Uvarint(); err != nil { // flags
+				return err
+			}
+			if setting.Value, err = decoder.String(); err != nil {
+				return err
+			}
+		}
+		*s = append(*s, setting)
+	}
+}
+
 func (s *Setting) encode(encoder *binary.Encoder, revision uint64) error {
 	if err := encoder.String(s.Key); err != nil {
 		return err
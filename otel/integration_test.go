@@ -0,0 +1,104 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otel
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// server skips the test when no server listens on the address of the integration tests.
+func server(t *testing.T) string {
+	const addr = "127.0.0.1:8463"
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Skipf("no server at %s: %v", addr, err)
+	}
+	conn.Close()
+	return addr
+}
+
+// TestInstrumentationIntegration runs against the server of the tests package, it is skipped
+// when that server is not running.
+func TestInstrumentationIntegration(t *testing.T) {
+	var (
+		spans     = tracetest.NewSpanRecorder()
+		reader    = sdkmetric.NewManualReader()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{server(t)},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Compression: &proton.Compression{
+				Method: proton.CompressionLZ4,
+			},
+			Instrumentation: New(
+				sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test"),
+				sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"),
+			),
+		})
+	)
+	if assert.NoError(t, err) {
+		defer conn.Close()
+		ctx := context.Background()
+		var count uint64
+		if err := conn.QueryRow(ctx, "SELECT count() FROM (SELECT number FROM system.numbers LIMIT 5)").Scan(&count); assert.NoError(t, err) {
+			assert.Equal(t, uint64(5), count)
+		}
+		assert.Error(t, conn.Exec(ctx, "SELECT * FROM table_that_does_not_exist"))
+		names := make(map[string]int)
+		for _, span := range spans.Ended() {
+			names[span.Name()]++
+			if span.Name() == "proton.exec" {
+				var code bool
+				for _, attr := range span.Attributes() {
+					code = code || attr.Key == "proton.exception.code"
+				}
+				assert.True(t, code, "the exception code is missing")
+			}
+		}
+		for _, name := range []string{"proton.dial", "proton.handshake", "proton.query", "proton.exec"} {
+			assert.NotZero(t, names[name], name)
+		}
+		var rm metricdata.ResourceMetrics
+		if assert.NoError(t, reader.Collect(ctx, &rm)) && assert.Len(t, rm.ScopeMetrics, 1) {
+			metrics := make(map[string]bool)
+			for _, m := range rm.ScopeMetrics[0].Metrics {
+				metrics[m.Name] = true
+			}
+			for _, name := range []string{
+				"proton.client.operation.duration",
+				"proton.client.network.io",
+				"proton.client.connections.usage",
+			} {
+				assert.True(t, metrics[name], name)
+			}
+		}
+	}
+}
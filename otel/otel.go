@@ -0,0 +1,249 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package otel instruments the driver with OpenTelemetry spans and metrics. It is a module of
+// its own, so the applications that do not use it do not depend on OpenTelemetry:
+//
+//	conn, err := proton.Open(&proton.Options{
+//		Addr:            []string{"127.0.0.1:8463"},
+//		Instrumentation: protonotel.New(otel.Tracer("proton"), otel.Meter("proton")),
+//	})
+package otel
+
+import (
+	"context"
+	"errors"
+
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	attrOperation     = attribute.Key("proton.operation")
+	attrQueryID       = attribute.Key("proton.query_id")
+	attrRowsRead      = attribute.Key("proton.rows_read")
+	attrBytesRead     = attribute.Key("proton.bytes_read")
+	attrRowsWritten   = attribute.Key("proton.rows_written")
+	attrBytesWritten  = attribute.Key("proton.bytes_written")
+	attrResultRows    = attribute.Key("proton.result_rows")
+	attrResultBytes   = attribute.Key("proton.result_bytes")
+	attrBatchRows     = attribute.Key("proton.batch_rows")
+	attrExceptionCode = attribute.Key("proton.exception.code")
+	attrExceptionName = attribute.Key("proton.exception.name")
+	attrDirection     = attribute.Key("direction")
+	attrCompressed    = attribute.Key("compressed")
+	attrError         = attribute.Key("error")
+	attrNetPeerName   = attribute.Key("net.peer.name")
+	attrDBSystem      = attribute.Key("db.system")
+	attrDBStatement   = attribute.Key("db.statement")
+	attrDBName        = attribute.Key("db.name")
+)
+
+var (
+	_ proton.Instrumentation = (*Instrumentation)(nil)
+	_ proton.PoolObserver    = (*Instrumentation)(nil)
+)
+
+// Instrumentation is the OpenTelemetry instrumentation of the driver, for Options.Instrumentation.
+type Instrumentation struct {
+	tracer   trace.Tracer
+	meter    metric.Meter
+	duration metric.Float64Histogram
+	bytes    metric.Int64Counter
+}
+
+// New returns the instrumentation of the driver, tracer and meter are both optional.
+func New(tracer trace.Tracer, meter metric.Meter) *Instrumentation {
+	i := Instrumentation{
+		tracer: tracer,
+		meter:  meter,
+	}
+	if meter != nil {
+		var err error
+		if i.duration, err = meter.Float64Histogram("proton.client.operation.duration",
+			metric.WithUnit("s"),
+			metric.WithDescription("Duration of the driver operations"),
+		); err != nil {
+			otel.Handle(err)
+		}
+		if i.bytes, err = meter.Int64Counter("proton.client.network.io",
+			metric.WithUnit("By"),
+			metric.WithDescription("Bytes exchanged with the server, compressed is the traffic on the wire and uncompressed the same traffic before compression"),
+		); err != nil {
+			otel.Handle(err)
+		}
+	}
+	return &i
+}
+
+// Start starts the span of an operation, the measurements of the dials and the handshakes
+// are only recorded in their spans.
+func (i *Instrumentation) Start(ctx context.Context, info proton.OperationInfo) (context.Context, func(proton.OperationStats)) {
+	var span trace.Span
+	if i.tracer != nil {
+		attrs := []attribute.KeyValue{
+			attrDBSystem.String("proton"),
+			attrNetPeerName.String(info.Addr),
+		}
+		if !connection(info.Operation) {
+			attrs = append(attrs,
+				attrOperation.String(info.Operation),
+				attrDBName.String(info.Database),
+			)
+			if len(info.Query) != 0 {
+				attrs = append(attrs, attrDBStatement.String(info.Query))
+			}
+			if len(info.QueryID) != 0 {
+				attrs = append(attrs, attrQueryID.String(info.QueryID))
+			}
+		}
+		ctx, span = i.tracer.Start(ctx, "proton."+info.Operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...),
+		)
+	}
+	return ctx, func(stats proton.OperationStats) {
+		if span != nil {
+			if !connection(stats.Operation) {
+				span.SetAttributes(operationAttributes(stats)...)
+			}
+			endSpan(span, stats.Err)
+		}
+		if !connection(stats.Operation) {
+			// the measurements are recorded even if the operation was canceled
+			i.record(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), stats)
+		}
+	}
+}
+
+func connection(operation string) bool {
+	return operation == "dial" || operation == "handshake"
+}
+
+func operationAttributes(stats proton.OperationStats) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attrRowsRead.Int64(int64(stats.Progress.Rows)),
+		attrBytesRead.Int64(int64(stats.Progress.Bytes)),
+		attrRowsWritten.Int64(int64(stats.Progress.WroteRows)),
+		attrBytesWritten.Int64(int64(stats.Progress.WroteBytes)),
+	}
+	if stats.Profile != nil {
+		attrs = append(attrs,
+			attrResultRows.Int64(int64(stats.Profile.Rows)),
+			attrResultBytes.Int64(int64(stats.Profile.Bytes)),
+		)
+	}
+	if stats.BatchRows != 0 {
+		attrs = append(attrs, attrBatchRows.Int(stats.BatchRows))
+	}
+	return attrs
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		var exception *proton.Exception
+		if errors.As(err, &exception) {
+			span.SetAttributes(
+				attrExceptionCode.Int64(int64(exception.Code)),
+				attrExceptionName.String(exception.Name),
+			)
+		}
+	}
+	span.End()
+}
+
+func (i *Instrumentation) record(ctx context.Context, stats proton.OperationStats) {
+	if i.duration != nil {
+		i.duration.Record(ctx, stats.Duration.Seconds(), metric.WithAttributes(
+			attrOperation.String(stats.Operation),
+			attrError.Bool(stats.Err != nil),
+		))
+	}
+	if i.bytes != nil {
+		for _, m := range []struct {
+			direction  string
+			compressed bool
+			value      uint64
+		}{
+			{"read", true, stats.Network.WireRead},
+			{"write", true, stats.Network.WireWritten},
+			{"read", false, stats.Network.DataRead},
+			{"write", false, stats.Network.DataWritten},
+		} {
+			i.bytes.Add(ctx, int64(m.value), metric.WithAttributes(
+				attrDirection.String(m.direction),
+				attrCompressed.Bool(m.compressed),
+			))
+		}
+	}
+}
+
+// ObservePool registers the pool gauges, the registration is removed by Close.
+func (i *Instrumentation) ObservePool(stats func() driver.Stats) func() {
+	if i.meter == nil {
+		return nil
+	}
+	var (
+		usage, err1 = i.meter.Int64ObservableUpDownCounter("proton.client.connections.usage",
+			metric.WithUnit("{connection}"),
+			metric.WithDescription("Connections in the pool by state"),
+		)
+		maxOpen, err2 = i.meter.Int64ObservableUpDownCounter("proton.client.connections.max",
+			metric.WithUnit("{connection}"),
+			metric.WithDescription("Maximum number of open connections"),
+		)
+		waits, err3 = i.meter.Int64ObservableCounter("proton.client.connections.wait_count",
+			metric.WithUnit("{wait}"),
+			metric.WithDescription("Acquires that waited for a free connection"),
+		)
+		waitTime, err4 = i.meter.Float64ObservableCounter("proton.client.connections.wait_time",
+			metric.WithUnit("s"),
+			metric.WithDescription("Time spent waiting for a free connection"),
+		)
+	)
+	for _, err := range []error{err1, err2, err3, err4} {
+		if err != nil {
+			otel.Handle(err)
+			return nil
+		}
+	}
+	registration, err := i.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		stats := stats()
+		o.ObserveInt64(usage, int64(stats.Open), metric.WithAttributes(attribute.String("state", "used")))
+		o.ObserveInt64(usage, int64(stats.Idle), metric.WithAttributes(attribute.String("state", "idle")))
+		o.ObserveInt64(maxOpen, int64(stats.MaxOpenConns))
+		o.ObserveInt64(waits, stats.WaitCount)
+		o.ObserveFloat64(waitTime, stats.WaitDuration.Seconds())
+		return nil
+	}, usage, maxOpen, waits, waitTime)
+	if err != nil {
+		otel.Handle(err)
+		return nil
+	}
+	return func() {
+		if err := registration.Unregister(); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
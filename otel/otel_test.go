@@ -0,0 +1,134 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestInstrumentation() (*Instrumentation, *tracetest.SpanRecorder, sdkmetric.Reader) {
+	var (
+		spans  = tracetest.NewSpanRecorder()
+		reader = sdkmetric.NewManualReader()
+	)
+	return New(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test"),
+		sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"),
+	), spans, reader
+}
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestInstrumentation(t *testing.T) {
+	parent := make(chan trace.SpanContext, 1)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		if q.Body == "SELECT 1" {
+			parent <- q.Span
+			return nil
+		}
+		return &proto.Exception{Code: 60, Name: "DB::Exception", Message: "stream does not exist"}
+	}))
+	defer srv.Close()
+	instrumentation, spans, reader := newTestInstrumentation()
+	conn, err := proton.Open(&proton.Options{
+		Addr:            []string{srv.Addr()},
+		MaxOpenConns:    7,
+		Instrumentation: instrumentation,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+	require.NoError(t, conn.Ping(ctx))
+	require.NoError(t, conn.Exec(ctx, "SELECT 1"))
+	assert.Error(t, conn.Exec(ctx, "SELECT * FROM example"))
+
+	ended := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range spans.Ended() {
+		ended[s.Name()] = s
+	}
+	for _, name := range []string{"proton.dial", "proton.handshake", "proton.ping"} {
+		if s, ok := ended[name]; assert.True(t, ok, name) {
+			assert.Equal(t, codes.Unset, s.Status().Code)
+			assert.Contains(t, s.Attributes(), attrDBSystem.String("proton"))
+		}
+	}
+	if s, ok := ended["proton.exec"]; assert.True(t, ok) {
+		assert.Equal(t, codes.Error, s.Status().Code)
+		assert.Contains(t, s.Attributes(), attrOperation.String("exec"))
+		assert.Contains(t, s.Attributes(), attrExceptionCode.Int64(60))
+		assert.Contains(t, s.Attributes(), attrExceptionName.String("DB::Exception"))
+	}
+	// the span of an operation is the parent of the query on the server
+	assert.True(t, (<-parent).IsValid())
+
+	metrics := collect(t, reader)
+	if duration, ok := metrics["proton.client.operation.duration"].(metricdata.Histogram[float64]); assert.True(t, ok) {
+		var count uint64
+		for _, dp := range duration.DataPoints {
+			op, _ := dp.Attributes.Value(attrOperation)
+			assert.Contains(t, []string{"ping", "exec"}, op.AsString())
+			count += dp.Count
+		}
+		assert.Equal(t, uint64(3), count)
+	}
+	if bytes, ok := metrics["proton.client.network.io"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Len(t, bytes.DataPoints, 4)
+		for _, dp := range bytes.DataPoints {
+			assert.NotZero(t, dp.Value, dp.Attributes.Encoded(attribute.DefaultEncoder()))
+		}
+	}
+	if maxOpen, ok := metrics["proton.client.connections.max"].(metricdata.Sum[int64]); assert.True(t, ok) && assert.Len(t, maxOpen.DataPoints, 1) {
+		assert.Equal(t, int64(7), maxOpen.DataPoints[0].Value)
+	}
+	if usage, ok := metrics["proton.client.connections.usage"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		assert.Len(t, usage.DataPoints, 2)
+	}
+}
+
+func TestInstrumentationWithoutMeter(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	instrumentation := New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test"), nil)
+	assert.Nil(t, instrumentation.ObservePool(nil))
+	_, end := instrumentation.Start(context.Background(), proton.OperationInfo{Operation: "ping", Addr: "127.0.0.1:8463"})
+	end(proton.OperationStats{Operation: "ping"})
+	assert.Len(t, spans.Ended(), 1)
+}
@@ -0,0 +1,221 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package prometheus exports the metrics of the driver to Prometheus.
+//
+//	collector := prometheus.NewCollector(prometheus.Opts{})
+//	conn, err := proton.Open(&proton.Options{
+//		Addr:        []string{"127.0.0.1:8463"},
+//		OnOperation: collector.Observe,
+//	})
+//	collector.Watch(conn)
+//	registry.MustRegister(collector)
+package prometheus
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// Opts configures the metric names and labels of a Collector.
+type Opts struct {
+	// Namespace prefixes the metric names, "proton" by default.
+	Namespace   string
+	ConstLabels prom.Labels
+	// Buckets of the operation duration histogram, prometheus.DefBuckets by default.
+	Buckets []float64
+}
+
+// Collector is a prometheus.Collector for the pool stats of a connection, see Watch, and
+// for the operations passed to Observe, which is meant to be used as Options.OnOperation.
+type Collector struct {
+	mu   sync.RWMutex
+	conn driver.Conn
+
+	duration     *prom.HistogramVec
+	rowsRead     *prom.CounterVec
+	bytesRead    *prom.CounterVec
+	rowsWritten  *prom.CounterVec
+	bytesWritten *prom.CounterVec
+	errors       *prom.CounterVec
+	exceptions   *prom.CounterVec
+
+	open            *prom.Desc
+	idle            *prom.Desc
+	maxOpen         *prom.Desc
+	maxIdle         *prom.Desc
+	waitCount       *prom.Desc
+	waitDuration    *prom.Desc
+	closed          *prom.Desc
+	dials           *prom.Desc
+	dialErrors      *prom.Desc
+	acquireTimeouts *prom.Desc
+	hostHealthy     *prom.Desc
+	hostOpen        *prom.Desc
+	hostFailures    *prom.Desc
+}
+
+func NewCollector(opts Opts) *Collector {
+	if len(opts.Namespace) == 0 {
+		opts.Namespace = "proton"
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prom.DefBuckets
+	}
+	var (
+		counter = func(name, help string, labels ...string) *prom.CounterVec {
+			return prom.NewCounterVec(prom.CounterOpts{
+				Namespace:   opts.Namespace,
+				Name:        name,
+				Help:        help,
+				ConstLabels: opts.ConstLabels,
+			}, labels)
+		}
+		desc = func(name, help string, labels ...string) *prom.Desc {
+			return prom.NewDesc(prom.BuildFQName(opts.Namespace, "", name), help, labels, opts.ConstLabels)
+		}
+	)
+	return &Collector{
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Duration of the driver operations.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.Buckets,
+		}, []string{"operation"}),
+		rowsRead:     counter("rows_read_total", "Rows read by the server, from the progress packets.", "operation"),
+		bytesRead:    counter("bytes_read_total", "Bytes read by the server, from the progress packets.", "operation"),
+		rowsWritten:  counter("rows_written_total", "Rows written by the server, from the progress packets.", "operation"),
+		bytesWritten: counter("bytes_written_total", "Bytes written by the server, from the progress packets.", "operation"),
+		errors:       counter("operation_errors_total", "Operations that failed.", "operation"),
+		exceptions:   counter("exceptions_total", "Exceptions returned by the server by code.", "operation", "code"),
+
+		open:            desc("connections_open", "Connections in use."),
+		idle:            desc("connections_idle", "Idle connections in the pool."),
+		maxOpen:         desc("connections_max_open", "Maximum number of open connections."),
+		maxIdle:         desc("connections_max_idle", "Maximum number of idle connections."),
+		waitCount:       desc("connections_wait_total", "Acquires that waited for a free connection."),
+		waitDuration:    desc("connections_wait_seconds_total", "Time spent waiting for a free connection."),
+		closed:          desc("connections_closed_total", "Connections closed by the pool by reason.", "reason"),
+		dials:           desc("dials_total", "Connections dialed."),
+		dialErrors:      desc("dial_errors_total", "Dials that failed."),
+		acquireTimeouts: desc("acquire_timeouts_total", "Acquires that failed with ErrAcquireConnTimeout."),
+		hostHealthy:     desc("host_healthy", "Whether the host is healthy.", "addr"),
+		hostOpen:        desc("host_connections_open", "Open connections to the host.", "addr"),
+		hostFailures:    desc("host_failures", "Consecutive failures of the host.", "addr"),
+	}
+}
+
+// Watch exports the Stats of conn, replacing the connection watched before.
+func (c *Collector) Watch(conn driver.Conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn = conn
+}
+
+// Observe records the measurements of an operation.
+func (c *Collector) Observe(stats driver.OperationStats) {
+	op := stats.Operation
+	c.duration.WithLabelValues(op).Observe(stats.Duration.Seconds())
+	c.rowsRead.WithLabelValues(op).Add(float64(stats.Progress.Rows))
+	c.bytesRead.WithLabelValues(op).Add(float64(stats.Progress.Bytes))
+	c.rowsWritten.WithLabelValues(op).Add(float64(stats.Progress.WroteRows))
+	c.bytesWritten.WithLabelValues(op).Add(float64(stats.Progress.WroteBytes))
+	if stats.Err == nil {
+		return
+	}
+	c.errors.WithLabelValues(op).Inc()
+	var exception *proto.Exception
+	if errors.As(stats.Err, &exception) {
+		c.exceptions.WithLabelValues(op, strconv.Itoa(int(exception.Code))).Inc()
+	}
+}
+
+func (c *Collector) vectors() []prom.Collector {
+	return []prom.Collector{
+		c.duration,
+		c.rowsRead,
+		c.bytesRead,
+		c.rowsWritten,
+		c.bytesWritten,
+		c.errors,
+		c.exceptions,
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, v := range c.vectors() {
+		v.Describe(ch)
+	}
+	for _, desc := range []*prom.Desc{
+		c.open, c.idle, c.maxOpen, c.maxIdle,
+		c.waitCount, c.waitDuration, c.closed,
+		c.dials, c.dialErrors, c.acquireTimeouts,
+		c.hostHealthy, c.hostOpen, c.hostFailures,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	for _, v := range c.vectors() {
+		v.Collect(ch)
+	}
+	c.mu.RLock()
+	conn := c.conn
+	c.mu.RUnlock()
+	if conn == nil {
+		return
+	}
+	var (
+		stats = conn.Stats()
+		gauge = func(desc *prom.Desc, value float64, labels ...string) {
+			ch <- prom.MustNewConstMetric(desc, prom.GaugeValue, value, labels...)
+		}
+		counter = func(desc *prom.Desc, value float64, labels ...string) {
+			ch <- prom.MustNewConstMetric(desc, prom.CounterValue, value, labels...)
+		}
+	)
+	gauge(c.open, float64(stats.Open))
+	gauge(c.idle, float64(stats.Idle))
+	gauge(c.maxOpen, float64(stats.MaxOpenConns))
+	gauge(c.maxIdle, float64(stats.MaxIdleConns))
+	counter(c.waitCount, float64(stats.WaitCount))
+	counter(c.waitDuration, stats.WaitDuration.Seconds())
+	counter(c.closed, float64(stats.MaxIdleClosed), "max_idle")
+	counter(c.closed, float64(stats.MaxIdleTimeClosed), "max_idle_time")
+	counter(c.closed, float64(stats.MaxLifetimeClosed), "max_lifetime")
+	counter(c.dials, float64(stats.Dials))
+	counter(c.dialErrors, float64(stats.DialErrors))
+	counter(c.acquireTimeouts, float64(stats.AcquireTimeouts))
+	for _, host := range stats.Hosts {
+		var healthy float64
+		if host.Healthy {
+			healthy = 1
+		}
+		gauge(c.hostHealthy, healthy, host.Addr)
+		gauge(c.hostOpen, float64(host.Open), host.Addr)
+		gauge(c.hostFailures, float64(host.Failures), host.Addr)
+	}
+}
+
+var _ prom.Collector = (*Collector)(nil)
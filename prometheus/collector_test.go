@@ -0,0 +1,98 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/driver"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+func TestCollectorObserve(t *testing.T) {
+	c := NewCollector(Opts{})
+	c.Observe(driver.OperationStats{
+		Operation: "query",
+		Duration:  20 * time.Millisecond,
+		Progress:  proto.Progress{Rows: 10, Bytes: 80},
+	})
+	c.Observe(driver.OperationStats{
+		Operation: "batch.send",
+		Duration:  time.Second,
+		Progress:  proto.Progress{WroteRows: 5, WroteBytes: 40},
+		Err:       fmt.Errorf("send: %w", &proto.Exception{Code: 60}),
+	})
+	c.Observe(driver.OperationStats{Operation: "query", Err: errors.New("broken pipe")})
+
+	assert.Equal(t, 10.0, testutil.ToFloat64(c.rowsRead.WithLabelValues("query")))
+	assert.Equal(t, 80.0, testutil.ToFloat64(c.bytesRead.WithLabelValues("query")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(c.rowsWritten.WithLabelValues("batch.send")))
+	assert.Equal(t, 40.0, testutil.ToFloat64(c.bytesWritten.WithLabelValues("batch.send")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.errors.WithLabelValues("query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.exceptions.WithLabelValues("batch.send", "60")))
+	assert.Equal(t, 1, testutil.CollectAndCount(c.exceptions))
+	assert.Equal(t, 2, testutil.CollectAndCount(c.duration))
+}
+
+func TestCollectorStats(t *testing.T) {
+	c := NewCollector(Opts{Namespace: "test", ConstLabels: prom.Labels{"pool": "main"}})
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+			return nil, errors.New("unreachable")
+		},
+		MaxOpenConns: 3,
+		MaxIdleConns: 2,
+		OnOperation:  c.Observe,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	c.Watch(conn)
+	require.Error(t, conn.Ping(context.Background()))
+
+	registry := prom.NewPedanticRegistry()
+	require.NoError(t, registry.Register(c))
+	expected := `
+# HELP test_connections_max_open Maximum number of open connections.
+# TYPE test_connections_max_open gauge
+test_connections_max_open{pool="main"} 3
+# HELP test_dial_errors_total Dials that failed.
+# TYPE test_dial_errors_total counter
+test_dial_errors_total{pool="main"} 1
+# HELP test_host_healthy Whether the host is healthy.
+# TYPE test_host_healthy gauge
+test_host_healthy{addr="127.0.0.1:8463",pool="main"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_connections_max_open", "test_dial_errors_total", "test_host_healthy",
+	))
+	count, err := testutil.GatherAndCount(registry, "test_connections_closed_total")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,406 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protontest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/compress"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/io"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// ErrCanceled is returned by ResponseWriter.Insert when the client cancels the query.
+var ErrCanceled = errors.New("protontest: query canceled by the client")
+
+// packet is a packet read from the client.
+type packet struct {
+	kind  byte
+	query *proto.Query
+	// canceled is closed by a cancel of the query, from the time the query is read.
+	canceled chan struct{}
+	name     string
+	block    *proto.Block
+	err      error
+}
+
+type conn struct {
+	srv      *Server
+	conn     net.Conn
+	stream   *io.Stream
+	encoder  *binary.Encoder
+	decoder  *binary.Decoder
+	revision uint64
+	database string
+	username string
+	packets  chan packet
+	mu       sync.Mutex
+	canceled chan struct{}
+	// method and level are the compression of the responses, the client asks for
+	// ZSTD with the network_compression_method setting.
+	method compress.Method
+	level  int
+}
+
+func newConn(srv *Server, c net.Conn) *conn {
+	stream := io.NewStream(c)
+	return &conn{
+		srv:     srv,
+		conn:    c,
+		stream:  stream,
+		encoder: binary.NewEncoder(stream),
+		decoder: binary.NewDecoder(stream),
+		packets: make(chan packet),
+		method:  compress.LZ4,
+	}
+}
+
+func (c *conn) serve() {
+	if err := c.handshake(); err != nil {
+		return
+	}
+	go c.read()
+	for p := range c.packets {
+		var err error
+		switch p.kind {
+		case proto.ClientPing:
+			if err = c.encoder.Byte(proto.ServerPong); err == nil {
+				err = c.encoder.Flush()
+			}
+		case proto.ClientQuery:
+			err = c.query(p.query, p.canceled)
+		case proto.ClientCancel:
+			// the query has already ended
+		default:
+			err = p.err
+		}
+		if err != nil {
+			c.conn.Close()
+			for range c.packets {
+			}
+			return
+		}
+	}
+}
+
+func (c *conn) handshake() error {
+	packet, err := c.decoder.ReadByte()
+	if err != nil {
+		return err
+	}
+	if packet != proto.ClientHello {
+		return fmt.Errorf("protontest: unexpected packet %d before hello", packet)
+	}
+	if _, err := c.decoder.String(); err != nil { // client name
+		return err
+	}
+	for i := 0; i < 2; i++ { // client version
+		if _, err := c.decoder.Uvarint(); err != nil {
+			return err
+		}
+	}
+	if c.revision, err = c.decoder.Uvarint(); err != nil {
+		return err
+	}
+	var password string
+	for _, v := range []*string{&c.database, &c.username, &password} {
+		if *v, err = c.decoder.String(); err != nil {
+			return err
+		}
+	}
+	if c.srv.Auth != nil {
+		if err := c.srv.Auth(c.database, c.username, password); err != nil {
+			c.encoder.Byte(proto.ServerException)
+			exception(err).Encode(c.encoder)
+			c.encoder.Flush()
+			return err
+		}
+	}
+	if c.revision > c.srv.Revision {
+		c.revision = c.srv.Revision
+	}
+	hello := proto.ServerHandshake{
+		Name:        "Proton",
+		DisplayName: "protontest",
+		Revision:    c.srv.Revision,
+		Timezone:    c.srv.Timezone,
+	}
+	hello.Version.Major, hello.Version.Minor, hello.Version.Patch = 1, 0, 0
+	if err := c.encoder.Byte(proto.ServerHello); err != nil {
+		return err
+	}
+	if err := hello.Encode(c.encoder); err != nil {
+		return err
+	}
+	if err := c.encoder.Flush(); err != nil {
+		return err
+	}
+	if c.revision >= proto.DBMS_MIN_PROTOCOL_VERSION_WITH_ADDENDUM {
+		if _, err := c.decoder.String(); err != nil { // quota key
+			return err
+		}
+	}
+	return nil
+}
+
+// read decodes the packets of the client until the connection is closed. A cancel
+// is signaled to the running query right away, the writes of a handler do not block it.
+// The cancel of a query is set up as soon as the query is read, so that a cancel sent
+// before the handler runs is not lost.
+func (c *conn) read() {
+	defer close(c.packets)
+	var compression bool
+	for {
+		kind, err := c.decoder.ReadByte()
+		if err != nil {
+			return
+		}
+		p := packet{kind: kind}
+		switch kind {
+		case proto.ClientQuery:
+			p.query = &proto.Query{}
+			if p.err = p.query.Decode(c.decoder, c.revision); p.err == nil {
+				compression = p.query.Compression
+				p.canceled = make(chan struct{})
+				c.mu.Lock()
+				c.canceled = p.canceled
+				c.mu.Unlock()
+			}
+		case proto.ClientData:
+			if p.name, p.err = c.decoder.String(); p.err != nil {
+				break
+			}
+			if compression {
+				c.stream.CompressRead(true)
+			}
+			p.block = &proto.Block{}
+			p.err = p.block.Decode(c.decoder, c.revision)
+			c.stream.CompressRead(false)
+		case proto.ClientCancel:
+			c.mu.Lock()
+			if c.canceled != nil {
+				close(c.canceled)
+				c.canceled = nil
+			}
+			c.mu.Unlock()
+		case proto.ClientPing:
+		default:
+			p.err = fmt.Errorf("protontest: unexpected packet %d", kind)
+		}
+		c.packets <- p
+		if p.err != nil {
+			return
+		}
+	}
+}
+
+func (c *conn) query(q *proto.Query, canceled chan struct{}) error {
+	query := Query{
+		ID:         q.ID,
+		Body:       q.Body,
+		Database:   c.database,
+		Username:   c.username,
+		QuotaKey:   q.QuotaKey,
+		Revision:   c.revision,
+		Settings:   make(map[string]string, len(q.Settings)),
+		Parameters: q.Parameters,
+		External:   make(map[string]*proto.Block),
+		Span:       q.Span,
+	}
+	for _, s := range q.Settings {
+		query.Settings[s.Key] = fmt.Sprint(s.Value)
+	}
+	if q.Compression {
+		c.compression(query.Settings)
+	}
+	// the external tables are followed by an empty block
+	for {
+		block, name, err := c.data()
+		if err != nil {
+			return err
+		}
+		if len(block.Columns) == 0 {
+			break
+		}
+		query.External[name] = block
+	}
+	w := ResponseWriter{
+		conn:        c,
+		compression: q.Compression,
+		canceled:    canceled,
+	}
+	err := c.srv.Handler.ServeQuery(&w, &query)
+	c.mu.Lock()
+	c.canceled = nil
+	c.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	if err != nil {
+		if err := c.encoder.Byte(proto.ServerException); err != nil {
+			return err
+		}
+		if err := exception(err).Encode(c.encoder); err != nil {
+			return err
+		}
+		return c.encoder.Flush()
+	}
+	if err := c.encoder.Byte(proto.ServerEndOfStream); err != nil {
+		return err
+	}
+	return c.encoder.Flush()
+}
+
+// compression sets the method of the compressed responses from the settings of a query.
+func (c *conn) compression(settings map[string]string) {
+	method, level := compress.Method(compress.LZ4), 0
+	if strings.EqualFold(settings["network_compression_method"], "zstd") {
+		method = compress.ZSTD
+		level, _ = strconv.Atoi(settings["network_zstd_compression_level"])
+	}
+	if method != c.method || level != c.level {
+		c.method, c.level = method, level
+		c.stream.SetCompression(method, level)
+	}
+}
+
+// data returns the next data packet of the client.
+func (c *conn) data() (*proto.Block, string, error) {
+	for p := range c.packets {
+		switch {
+		case p.err != nil:
+			return nil, "", p.err
+		case p.kind == proto.ClientData:
+			return p.block, p.name, nil
+		case p.kind == proto.ClientCancel:
+			return nil, "", ErrCanceled
+		}
+		return nil, "", fmt.Errorf("protontest: unexpected packet %d, expected data", p.kind)
+	}
+	return nil, "", net.ErrClosed
+}
+
+// ResponseWriter sends the response of a query to the client.
+type ResponseWriter struct {
+	conn        *conn
+	compression bool
+	canceled    chan struct{}
+	err         error
+}
+
+// Data sends a block of the result, the first block is also the header of the result.
+func (w *ResponseWriter) Data(block *proto.Block) error {
+	return w.data(proto.ServerData, block)
+}
+
+func (w *ResponseWriter) Totals(block *proto.Block) error {
+	return w.data(proto.ServerTotals, block)
+}
+
+func (w *ResponseWriter) Progress(progress proto.Progress) error {
+	return w.write(proto.ServerProgress, func() error {
+		return progress.Encode(w.conn.encoder, w.conn.revision)
+	})
+}
+
+func (w *ResponseWriter) ProfileInfo(info proto.ProfileInfo) error {
+	return w.write(proto.ServerProfileInfo, func() error {
+		return info.Encode(w.conn.encoder, w.conn.revision)
+	})
+}
+
+// ProfileEvents sends a block of profile events, with the host_name, current_time, thread_id,
+// type, name and value columns. It is never compressed.
+func (w *ResponseWriter) ProfileEvents(block *proto.Block) error {
+	return w.write(proto.ServerProfileEvents, func() error {
+		if err := w.conn.encoder.String(""); err != nil {
+			return err
+		}
+		return block.Encode(w.conn.encoder, w.conn.revision)
+	})
+}
+
+// Insert answers an INSERT: it sends header, the columns the client must send, and returns the
+// blocks of the client. It returns ErrCanceled if the client cancels the insert.
+func (w *ResponseWriter) Insert(header *proto.Block) ([]*proto.Block, error) {
+	if err := w.Data(header); err != nil {
+		return nil, err
+	}
+	var blocks []*proto.Block
+	for {
+		block, _, err := w.conn.data()
+		if err != nil {
+			if !errors.Is(err, ErrCanceled) {
+				w.err = err
+			}
+			return blocks, err
+		}
+		if len(block.Columns) == 0 {
+			return blocks, nil
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+// Canceled is closed when the client cancels the query. A handler that ignores it
+// keeps the query running, like a server that does not check for the cancel.
+func (w *ResponseWriter) Canceled() <-chan struct{} {
+	return w.canceled
+}
+
+func (w *ResponseWriter) data(packet byte, block *proto.Block) error {
+	return w.write(packet, func() error {
+		if err := w.conn.encoder.String(""); err != nil {
+			return err
+		}
+		if w.compression {
+			w.conn.stream.CompressWrite(true)
+			defer w.conn.stream.CompressWrite(false)
+		}
+		if err := block.Encode(w.conn.encoder, w.conn.revision); err != nil {
+			return err
+		}
+		// the compressed frame must end before the next packet
+		return w.conn.encoder.Flush()
+	})
+}
+
+// write sends a packet, an error breaks the connection.
+func (w *ResponseWriter) write(packet byte, body func() error) error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.conn.encoder.Byte(packet); err != nil {
+		w.err = err
+		return err
+	}
+	if err := body(); err != nil {
+		w.err = err
+		return err
+	}
+	if err := w.conn.encoder.Flush(); err != nil {
+		w.err = err
+	}
+	return w.err
+}
@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protontest
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/binary"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+// TestCancelBeforeHandler sends the cancel once the query and its data are read, before
+// a handler could have started.
+func TestCancelBeforeHandler(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	c := newConn(&Server{}, server)
+	c.revision = proto.ClientTCPProtocolVersion
+	go c.read()
+	go func() {
+		encoder := binary.NewEncoder(client)
+		encoder.Byte(proto.ClientQuery)
+		(&proto.Query{ID: "q1", Body: "SELECT 1"}).Encode(encoder, c.revision)
+		encoder.Byte(proto.ClientData)
+		encoder.String("")
+		(&proto.Block{}).Encode(encoder, c.revision)
+		encoder.Byte(proto.ClientCancel)
+	}()
+	query := <-c.packets
+	require.Equal(t, byte(proto.ClientQuery), query.kind)
+	_, _, err := c.data()
+	require.NoError(t, err)
+	select {
+	case <-query.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cancel was lost")
+	}
+	p := <-c.packets
+	require.Equal(t, byte(proto.ClientCancel), p.kind)
+	server.Close()
+}
@@ -0,0 +1,174 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package protontest is an in-process server of the native protocol, to test applications
+// against scripted responses instead of a Proton server.
+//
+//	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+//		var block proto.Block
+//		block.AddColumn("n", "uint64")
+//		block.Append(uint64(42))
+//		return w.Data(&block)
+//	}))
+//	defer srv.Close()
+//	conn, err := proton.Open(&proton.Options{Addr: []string{srv.Addr()}})
+package protontest
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Query is a query received by the server.
+type Query struct {
+	ID       string
+	Body     string
+	Database string
+	Username string
+	QuotaKey string
+	// Revision is the protocol revision negotiated with the client.
+	Revision   uint64
+	Settings   map[string]string
+	Parameters map[string]string
+	// External are the external tables sent with the query, by name.
+	External map[string]*proto.Block
+	Span     trace.SpanContext
+}
+
+// Handler answers the queries of the server. Returning a *proto.Exception sends it to the client,
+// any other error is sent as an exception with code 1002, and nil ends the query with end of stream.
+type Handler interface {
+	ServeQuery(w *ResponseWriter, q *Query) error
+}
+
+type HandlerFunc func(w *ResponseWriter, q *Query) error
+
+func (f HandlerFunc) ServeQuery(w *ResponseWriter, q *Query) error {
+	return f(w, q)
+}
+
+// Server is a server of the native protocol listening on a local port.
+type Server struct {
+	Handler Handler
+	// Auth checks the credentials of every connection, nil accepts all.
+	Auth func(database, username, password string) error
+	// Revision is the protocol revision of the server, by default the highest the driver speaks:
+	// proto.DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS.
+	Revision uint64
+	// Timezone of the server, UTC by default.
+	Timezone *time.Location
+	Listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewServer starts a server on 127.0.0.1 with a random port.
+func NewServer(handler Handler) *Server {
+	s := NewUnstartedServer(handler)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a server to configure before Start.
+func NewUnstartedServer(handler Handler) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("protontest: failed to listen on a port: " + err.Error())
+	}
+	return &Server{
+		Handler:  handler,
+		Listener: listener,
+	}
+}
+
+func (s *Server) Start() {
+	if s.Revision == 0 {
+		s.Revision = proto.DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS
+	}
+	if s.Timezone == nil {
+		s.Timezone = time.UTC
+	}
+	s.conns = make(map[net.Conn]struct{})
+	s.wg.Add(1)
+	go s.accept()
+}
+
+// Addr is the host:port of the server for Options.Addr.
+func (s *Server) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+// Close stops the server, closes its connections and waits for the handlers to return.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	err := s.Listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+		go func() {
+			defer s.wg.Done()
+			newConn(s, conn).serve()
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// exception is the exception sent for an error of a handler.
+func exception(err error) *proto.Exception {
+	var e *proto.Exception
+	if errors.As(err, &e) {
+		return e
+	}
+	return &proto.Exception{
+		Code:    1002,
+		Name:    "DB::Exception",
+		Message: err.Error(),
+	}
+}
@@ -0,0 +1,327 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package protontest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/compress"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+)
+
+func open(t *testing.T, srv *protontest.Server, compression *proton.Compression) proton.Conn {
+	conn, err := proton.Open(&proton.Options{
+		Addr:        []string{srv.Addr()},
+		Compression: compression,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func numbers(n int) *proto.Block {
+	var block proto.Block
+	block.AddColumn("n", "uint64")
+	block.AddColumn("s", "string")
+	for i := 0; i < n; i++ {
+		block.Append(uint64(i), "v")
+	}
+	return &block
+}
+
+func TestQuery(t *testing.T) {
+	for name, compression := range map[string]*proton.Compression{
+		"none": nil,
+		"lz4":  {Method: proton.CompressionLZ4},
+		"zstd": {Method: proton.CompressionZSTD},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var received *protontest.Query
+			srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+				received = q
+				if err := w.Data(numbers(0)); err != nil {
+					return err
+				}
+				if err := w.Progress(proto.Progress{Rows: 3}); err != nil {
+					return err
+				}
+				return w.Data(numbers(3))
+			}))
+			defer srv.Close()
+			ctx := proton.Context(context.Background(), proton.WithQueryID("q1"), proton.WithSettings(proton.Settings{
+				"max_threads": 2,
+			}))
+			rows, err := open(t, srv, compression).Query(ctx, "SELECT n, s FROM numbers")
+			require.NoError(t, err)
+			var ns []uint64
+			for rows.Next() {
+				var (
+					n uint64
+					s string
+				)
+				require.NoError(t, rows.Scan(&n, &s))
+				assert.Equal(t, "v", s)
+				ns = append(ns, n)
+			}
+			require.NoError(t, rows.Err())
+			require.NoError(t, rows.Close())
+			assert.Equal(t, []uint64{0, 1, 2}, ns)
+			if assert.NotNil(t, received) {
+				assert.Equal(t, "q1", received.ID)
+				assert.Equal(t, "SELECT n, s FROM numbers", received.Body)
+				assert.Equal(t, "default", received.Database)
+				assert.Equal(t, "2", received.Settings["max_threads"])
+			}
+		})
+	}
+}
+
+func TestException(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		return &proto.Exception{Code: 60, Name: "DB::Exception", Message: "stream does not exist"}
+	}))
+	defer srv.Close()
+	conn := open(t, srv, nil)
+	err := conn.Exec(context.Background(), "SELECT * FROM missing")
+	var exception *proton.Exception
+	if assert.True(t, errors.As(err, &exception)) {
+		assert.Equal(t, int32(60), exception.Code)
+	}
+	assert.True(t, proton.IsTableNotFound(err))
+	// the connection is still usable after an exception
+	assert.NoError(t, conn.Ping(context.Background()))
+}
+
+func TestAuth(t *testing.T) {
+	srv := protontest.NewUnstartedServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		return nil
+	}))
+	srv.Auth = func(database, username, password string) error {
+		if password != "secret" {
+			return &proto.Exception{Code: 516, Name: "DB::Exception", Message: "wrong password"}
+		}
+		return nil
+	}
+	srv.Start()
+	defer srv.Close()
+	assert.True(t, proton.IsAuthFailure(open(t, srv, nil).Ping(context.Background())))
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{srv.Addr()},
+		Auth: proton.Auth{Password: "secret"},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.NoError(t, conn.Ping(context.Background()))
+}
+
+func TestInsert(t *testing.T) {
+	received := make(chan []*proto.Block, 1)
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		blocks, err := w.Insert(numbers(0))
+		if err != nil {
+			return err
+		}
+		received <- blocks
+		return nil
+	}))
+	defer srv.Close()
+	batch, err := open(t, srv, &proton.Compression{Method: proton.CompressionLZ4}).PrepareBatch(context.Background(), "INSERT INTO example")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, batch.Append(uint64(i), "v"))
+	}
+	require.NoError(t, batch.Send())
+	blocks := <-received
+	if assert.Len(t, blocks, 1) {
+		assert.Equal(t, 10, blocks[0].Rows())
+		assert.Equal(t, []string{"n", "s"}, blocks[0].ColumnsNames())
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	canceled := make(chan struct{})
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		if err := w.Data(numbers(0)); err != nil {
+			return err
+		}
+		for {
+			select {
+			case <-w.Canceled():
+				close(canceled)
+				return nil
+			case <-time.After(10 * time.Millisecond):
+				if err := w.Data(numbers(1)); err != nil {
+					return err
+				}
+			}
+		}
+	}))
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := open(t, srv, nil).(proton.Streamer).Stream(ctx, "SELECT n, s FROM numbers")
+	require.NoError(t, err)
+	<-stream.Rows()
+	cancel()
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not receive the cancel")
+	}
+	stream.Close()
+}
+
+func TestStd(t *testing.T) {
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		return w.Data(numbers(1))
+	}))
+	defer srv.Close()
+	db := proton.OpenDB(&proton.Options{Addr: []string{srv.Addr()}})
+	defer db.Close()
+	var (
+		n uint64
+		s string
+	)
+	require.NoError(t, db.QueryRow("SELECT n, s FROM numbers").Scan(&n, &s))
+	assert.Equal(t, uint64(0), n)
+	assert.Equal(t, "v", s)
+}
+
+func TestRevision(t *testing.T) {
+	for _, tc := range []struct {
+		serverSideBinding bool
+		revision          uint64
+	}{
+		{false, proto.DBMS_TCP_PROTOCOL_VERSION},
+		{true, proto.DBMS_MIN_PROTOCOL_VERSION_WITH_PARAMETERS},
+	} {
+		received := make(chan *protontest.Query, 2)
+		inserted := make(chan []*proto.Block, 1)
+		srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+			received <- q
+			if q.Body == "INSERT INTO example VALUES" {
+				blocks, err := w.Insert(numbers(0))
+				inserted <- blocks
+				return err
+			}
+			return w.Data(numbers(2))
+		}))
+		conn, err := proton.Open(&proton.Options{
+			Addr:              []string{srv.Addr()},
+			ServerSideBinding: tc.serverSideBinding,
+		})
+		require.NoError(t, err)
+		ctx := context.Background()
+
+		rows, err := conn.Query(ctx, "SELECT n, s FROM numbers WHERE n < $1", 2)
+		require.NoError(t, err)
+		var n int
+		for rows.Next() {
+			n++
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, 2, n)
+		q := <-received
+		assert.Equal(t, tc.revision, q.Revision)
+		if tc.serverSideBinding {
+			assert.Equal(t, "SELECT n, s FROM numbers WHERE n < {p1:int64}", q.Body)
+			assert.Equal(t, map[string]string{"p1": "2"}, q.Parameters)
+		} else {
+			assert.Equal(t, "SELECT n, s FROM numbers WHERE n < 2", q.Body)
+		}
+
+		// the blocks of an insert carry the custom serialization flag from revision 54454
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO example")
+		require.NoError(t, err)
+		require.NoError(t, batch.Append(uint64(1), "a"))
+		require.NoError(t, batch.Send())
+		<-received
+		if blocks := <-inserted; assert.Len(t, blocks, 1) {
+			assert.Equal(t, 1, blocks[0].Rows())
+		}
+		conn.Close()
+		srv.Close()
+	}
+}
+
+// recordConn keeps the bytes the client reads from the server.
+type recordConn struct {
+	net.Conn
+	mu   sync.Mutex
+	read bytes.Buffer
+}
+
+func (c *recordConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.mu.Lock()
+	c.read.Write(p[:n])
+	c.mu.Unlock()
+	return n, err
+}
+
+func TestCompressionMethod(t *testing.T) {
+	// the frames of ZSTD start with its magic number
+	zstdMagic := []byte{0x28, 0xb5, 0x2f, 0xfd}
+	for _, tc := range []struct {
+		method compress.Method
+		zstd   bool
+	}{
+		{proton.CompressionLZ4, false},
+		{proton.CompressionZSTD, true},
+	} {
+		srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+			return w.Data(numbers(100))
+		}))
+		var recorded *recordConn
+		conn, err := proton.Open(&proton.Options{
+			Addr:        []string{srv.Addr()},
+			Compression: &proton.Compression{Method: tc.method},
+			DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+				c, err := net.Dial("tcp", addr)
+				if err != nil {
+					return nil, err
+				}
+				recorded = &recordConn{Conn: c}
+				return recorded, nil
+			},
+		})
+		require.NoError(t, err)
+		rows, err := conn.Query(context.Background(), "SELECT n, s FROM numbers")
+		require.NoError(t, err)
+		var n int
+		for rows.Next() {
+			n++
+		}
+		require.NoError(t, rows.Err())
+		assert.Equal(t, 100, n)
+		recorded.mu.Lock()
+		assert.Equal(t, tc.zstd, bytes.Contains(recorded.read.Bytes(), zstdMagic))
+		recorded.mu.Unlock()
+		conn.Close()
+		srv.Close()
+	}
+}
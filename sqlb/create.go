@@ -0,0 +1,140 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlb
+
+import "github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+
+// CreateStreamBuilder builds a CREATE STREAM. Column types, defaults and expressions are SQL
+// written by the caller.
+type CreateStreamBuilder struct {
+	stream      string
+	ifNotExists bool
+	columns     []streamColumn
+	primaryKey  []string
+	orderBy     []string
+	ttl         string
+	comment     string
+	settings    []Setting
+}
+
+type streamColumn struct {
+	name, typ, def string
+}
+
+func CreateStream(stream string) *CreateStreamBuilder {
+	return &CreateStreamBuilder{stream: stream}
+}
+
+func (c *CreateStreamBuilder) IfNotExists() *CreateStreamBuilder {
+	c.ifNotExists = true
+	return c
+}
+
+func (c *CreateStreamBuilder) Column(name, typ string) *CreateStreamBuilder {
+	c.columns = append(c.columns, streamColumn{name: name, typ: typ})
+	return c
+}
+
+// ColumnDefault adds a column with a DEFAULT expression.
+func (c *CreateStreamBuilder) ColumnDefault(name, typ, expr string) *CreateStreamBuilder {
+	c.columns = append(c.columns, streamColumn{name: name, typ: typ, def: expr})
+	return c
+}
+
+func (c *CreateStreamBuilder) PrimaryKey(columns ...string) *CreateStreamBuilder {
+	c.primaryKey = append(c.primaryKey, columns...)
+	return c
+}
+
+func (c *CreateStreamBuilder) OrderBy(columns ...string) *CreateStreamBuilder {
+	c.orderBy = append(c.orderBy, columns...)
+	return c
+}
+
+func (c *CreateStreamBuilder) TTL(expr string) *CreateStreamBuilder {
+	c.ttl = expr
+	return c
+}
+
+func (c *CreateStreamBuilder) Comment(comment string) *CreateStreamBuilder {
+	c.comment = comment
+	return c
+}
+
+// Mode sets the mode of the stream: append, versioned_kv, changelog_kv or changelog.
+func (c *CreateStreamBuilder) Mode(mode string) *CreateStreamBuilder {
+	return c.Settings("mode", mode)
+}
+
+// Settings adds a setting to the SETTINGS clause, the name must be a plain identifier.
+func (c *CreateStreamBuilder) Settings(name string, value interface{}) *CreateStreamBuilder {
+	c.settings = append(c.settings, Setting{Name: name, Value: value})
+	return c
+}
+
+// Build returns the query, it has no placeholders: the comment is rendered as a literal.
+// It fails on an invalid setting.
+func (c *CreateStreamBuilder) Build() (string, []interface{}, error) {
+	var b builder
+	b.write("CREATE STREAM ")
+	if c.ifNotExists {
+		b.write("IF NOT EXISTS ")
+	}
+	b.write(Ident(c.stream), " (")
+	for i, column := range c.columns {
+		if i != 0 {
+			b.write(", ")
+		}
+		b.write(Ident(column.name), " ", column.typ)
+		if column.def != "" {
+			b.write(" DEFAULT ", column.def)
+		}
+	}
+	b.write(")")
+	writeIdents(&b, " PRIMARY KEY ", c.primaryKey)
+	writeIdents(&b, " ORDER BY ", c.orderBy)
+	if c.ttl != "" {
+		b.write(" TTL ", c.ttl)
+	}
+	if c.comment != "" {
+		b.write(" COMMENT ", lexer.QuoteString(c.comment))
+	}
+	writeSettings(&b, c.settings)
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.sql.String(), nil, nil
+}
+
+func writeIdents(b *builder, clause string, columns []string) {
+	switch len(columns) {
+	case 0:
+		return
+	case 1:
+		b.write(clause, Ident(columns[0]))
+		return
+	}
+	b.write(clause, "(")
+	for i, column := range columns {
+		if i != 0 {
+			b.write(", ")
+		}
+		b.write(Ident(column))
+	}
+	b.write(")")
+}
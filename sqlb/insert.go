@@ -0,0 +1,78 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlb
+
+import "strings"
+
+// InsertBuilder builds an INSERT. Without values or a SELECT it is the query of Conn.PrepareBatch.
+type InsertBuilder struct {
+	stream  string
+	columns []string
+	rows    [][]interface{}
+	query   *SelectBuilder
+}
+
+func Insert(stream string) *InsertBuilder {
+	return &InsertBuilder{stream: stream}
+}
+
+func (i *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	i.columns = append(i.columns, columns...)
+	return i
+}
+
+// Values adds a row, each value is bound to a placeholder.
+func (i *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	i.rows = append(i.rows, values)
+	return i
+}
+
+// Select inserts the rows of a query instead of values.
+func (i *InsertBuilder) Select(query *SelectBuilder) *InsertBuilder {
+	i.query = query
+	return i
+}
+
+// Build returns the query and the values of its $N placeholders, or the error of an invalid setting
+// of its SELECT.
+func (i *InsertBuilder) Build() (string, []interface{}, error) {
+	var b builder
+	b.write("INSERT INTO ", Ident(i.stream))
+	if len(i.columns) != 0 {
+		quoted := make([]string, 0, len(i.columns))
+		for _, column := range i.columns {
+			quoted = append(quoted, Ident(column))
+		}
+		b.write(" (", strings.Join(quoted, ", "), ")")
+	}
+	switch {
+	case i.query != nil:
+		b.write(" ")
+		i.query.write(&b)
+	case len(i.rows) != 0:
+		b.write(" VALUES ")
+		for n, row := range i.rows {
+			if n != 0 {
+				b.write(", ")
+			}
+			b.write("(", strings.TrimSuffix(strings.Repeat("?, ", len(row)), ", "), ")")
+			b.args = append(b.args, row...)
+		}
+	}
+	return b.build()
+}
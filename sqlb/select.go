@@ -0,0 +1,312 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlb
+
+import (
+	"strconv"
+	"time"
+)
+
+// Window is a windowed read of a stream: tumble, hop or session.
+type Window struct {
+	fn         string
+	stream     string
+	table      bool
+	timeColumn string
+	intervals  []time.Duration
+}
+
+// Tumble splits a stream into fixed windows of size.
+func Tumble(stream string, size time.Duration) Window {
+	return Window{fn: "tumble", stream: stream, intervals: []time.Duration{size}}
+}
+
+// Hop splits a stream into windows of size that start every slide.
+func Hop(stream string, slide, size time.Duration) Window {
+	return Window{fn: "hop", stream: stream, intervals: []time.Duration{slide, size}}
+}
+
+// Session groups the events of a stream into sessions closed after timeout without an event.
+func Session(stream string, timeout time.Duration) Window {
+	return Window{fn: "session", stream: stream, intervals: []time.Duration{timeout}}
+}
+
+// TimeColumn sets the column the window is computed on, _tp_time by default.
+func (w Window) TimeColumn(column string) Window {
+	w.timeColumn = column
+	return w
+}
+
+// Table reads the historical data of the stream, table(stream), instead of the new events.
+func (w Window) Table() Window {
+	w.table = true
+	return w
+}
+
+// String renders the window function, an invalid interval makes Build of the query fail.
+func (w Window) String() string {
+	sql, _ := w.render()
+	return sql
+}
+
+func (w Window) render() (string, error) {
+	stream := Ident(w.stream)
+	if w.table {
+		stream = "table(" + stream + ")"
+	}
+	sql := w.fn + "(" + stream
+	if w.timeColumn != "" {
+		sql += ", " + Ident(w.timeColumn)
+	}
+	var err error
+	for _, d := range w.intervals {
+		s, e := interval(d)
+		if e != nil && err == nil {
+			err = e
+		}
+		sql += ", " + s
+	}
+	return sql + ")", err
+}
+
+// EmitPolicy is the EMIT clause of a streaming query.
+type EmitPolicy struct {
+	sql string
+	err error
+}
+
+func (e EmitPolicy) String() string {
+	return e.sql
+}
+
+func EmitPeriodic(d time.Duration) EmitPolicy {
+	s, err := interval(d)
+	return EmitPolicy{sql: "EMIT PERIODIC " + s, err: err}
+}
+
+func EmitAfterWatermark() EmitPolicy {
+	return EmitPolicy{sql: "EMIT AFTER WATERMARK"}
+}
+
+func EmitAfterWatermarkAndDelay(d time.Duration) EmitPolicy {
+	s, err := interval(d)
+	return EmitPolicy{sql: "EMIT AFTER WATERMARK AND DELAY " + s, err: err}
+}
+
+func EmitChangelog() EmitPolicy {
+	return EmitPolicy{sql: "EMIT CHANGELOG"}
+}
+
+func EmitOnUpdate() EmitPolicy {
+	return EmitPolicy{sql: "EMIT ON UPDATE"}
+}
+
+type orderBy struct {
+	column string
+	desc   bool
+}
+
+// SelectBuilder builds a SELECT. Its methods modify and return the builder.
+type SelectBuilder struct {
+	columns  []Expr
+	from     Expr
+	where    []Expr
+	groupBy  []string
+	having   []Expr
+	orderBy  []orderBy
+	limit    int
+	emit     EmitPolicy
+	settings []Setting
+	// err is the error of the subquery or the window of FROM, returned by Build.
+	err error
+}
+
+// Select starts a SELECT of columns, quoted as identifiers. Use Expr for expressions.
+func Select(columns ...string) *SelectBuilder {
+	s := &SelectBuilder{limit: -1}
+	for _, column := range columns {
+		s.columns = append(s.columns, E(Ident(column)))
+	}
+	return s
+}
+
+// Expr adds an expression to the selected columns.
+func (s *SelectBuilder) Expr(sql string, args ...interface{}) *SelectBuilder {
+	s.columns = append(s.columns, E(sql, args...))
+	return s
+}
+
+// From reads the new events of a stream.
+func (s *SelectBuilder) From(stream string) *SelectBuilder {
+	s.from = E(Ident(stream))
+	return s
+}
+
+// FromTable reads the historical data of a stream, table(stream).
+func (s *SelectBuilder) FromTable(stream string) *SelectBuilder {
+	s.from = E("table(" + Ident(stream) + ")")
+	return s
+}
+
+// FromWindow reads a stream through a window function, its rows have window_start and window_end.
+func (s *SelectBuilder) FromWindow(w Window) *SelectBuilder {
+	sql, err := w.render()
+	s.from = E(sql)
+	s.err = err
+	return s
+}
+
+// FromSelect reads the rows of a subquery.
+func (s *SelectBuilder) FromSelect(sub *SelectBuilder, alias string) *SelectBuilder {
+	var b builder
+	b.write("(")
+	sub.write(&b)
+	b.write(") AS ", Ident(alias))
+	s.from = E(b.sql.String(), b.args...)
+	s.err = b.err
+	return s
+}
+
+// Where adds a condition, the conditions are joined with AND.
+func (s *SelectBuilder) Where(sql string, args ...interface{}) *SelectBuilder {
+	s.where = append(s.where, E(sql, args...))
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, columns...)
+	return s
+}
+
+// Having adds a condition on the groups, the conditions are joined with AND.
+func (s *SelectBuilder) Having(sql string, args ...interface{}) *SelectBuilder {
+	s.having = append(s.having, E(sql, args...))
+	return s
+}
+
+func (s *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		s.orderBy = append(s.orderBy, orderBy{column: column})
+	}
+	return s
+}
+
+func (s *SelectBuilder) OrderByDesc(columns ...string) *SelectBuilder {
+	for _, column := range columns {
+		s.orderBy = append(s.orderBy, orderBy{column: column, desc: true})
+	}
+	return s
+}
+
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Emit(emit EmitPolicy) *SelectBuilder {
+	s.emit = emit
+	return s
+}
+
+// Settings adds a setting to the SETTINGS clause, the name must be a plain identifier.
+func (s *SelectBuilder) Settings(name string, value interface{}) *SelectBuilder {
+	s.settings = append(s.settings, Setting{Name: name, Value: value})
+	return s
+}
+
+// SeekTo starts a streaming query from a point of the stream: 'earliest', 'latest',
+// a time or a relative time like '-1h'.
+func (s *SelectBuilder) SeekTo(v interface{}) *SelectBuilder {
+	return s.Settings("seek_to", v)
+}
+
+// Build returns the query and the values of its $N placeholders, or the error of an invalid setting.
+func (s *SelectBuilder) Build() (string, []interface{}, error) {
+	var b builder
+	s.write(&b)
+	return b.build()
+}
+
+func (s *SelectBuilder) write(b *builder) {
+	if s.err != nil {
+		b.fail(s.err)
+	}
+	b.write("SELECT ")
+	if len(s.columns) == 0 {
+		b.write("*")
+	}
+	b.exprs(s.columns, ", ")
+	if s.from.SQL != "" {
+		b.write(" FROM ")
+		b.expr(s.from)
+	}
+	if len(s.where) != 0 {
+		b.write(" WHERE ")
+		writeConditions(b, s.where)
+	}
+	if len(s.groupBy) != 0 {
+		b.write(" GROUP BY ")
+		for i, column := range s.groupBy {
+			if i != 0 {
+				b.write(", ")
+			}
+			b.write(Ident(column))
+		}
+	}
+	if len(s.having) != 0 {
+		b.write(" HAVING ")
+		writeConditions(b, s.having)
+	}
+	if len(s.orderBy) != 0 {
+		b.write(" ORDER BY ")
+		for i, o := range s.orderBy {
+			if i != 0 {
+				b.write(", ")
+			}
+			b.write(Ident(o.column))
+			if o.desc {
+				b.write(" DESC")
+			}
+		}
+	}
+	if s.limit >= 0 {
+		b.write(" LIMIT ", strconv.Itoa(s.limit))
+	}
+	if s.emit.err != nil {
+		b.fail(s.emit.err)
+	}
+	if s.emit.sql != "" {
+		b.write(" ", s.emit.sql)
+	}
+	writeSettings(b, s.settings)
+}
+
+func writeConditions(b *builder, conditions []Expr) {
+	if len(conditions) == 1 {
+		b.expr(conditions[0])
+		return
+	}
+	for i, c := range conditions {
+		if i != 0 {
+			b.write(" AND ")
+		}
+		b.write("(")
+		b.expr(c)
+		b.write(")")
+	}
+}
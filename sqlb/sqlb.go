@@ -0,0 +1,202 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sqlb builds Proton queries with quoted identifiers and bound values.
+//
+// Identifiers (streams, columns, settings) are quoted or checked by the builders. Expressions
+// are SQL written by the caller, their values are passed as ? placeholders that Build numbers
+// $1, $2... for Conn.Query, Conn.Exec or database/sql:
+//
+//	query, args, err := sqlb.Select("device").
+//		Expr("avg(temperature)").
+//		FromWindow(sqlb.Tumble("readings", 5*time.Second)).
+//		Where("region = ?", region).
+//		GroupBy("device", "window_start").
+//		Emit(sqlb.EmitAfterWatermark()).
+//		Build()
+//	if err != nil {
+//		return err
+//	}
+//	rows, err := conn.Query(ctx, query, args...)
+package sqlb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timeplus-io/proton-go-driver/v2/lib/lexer"
+)
+
+// Ident quotes a name, a dotted name is quoted part by part: Ident("db.events") is `db`.`events`.
+// Use QuoteIdent for a name that has a dot.
+func Ident(name string) string {
+	return QuoteIdent(strings.Split(name, ".")...)
+}
+
+// QuoteIdent quotes the parts of a name and joins them with dots.
+func QuoteIdent(parts ...string) string {
+	quoted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		quoted = append(quoted, lexer.QuoteIdentifier(part))
+	}
+	return strings.Join(quoted, ".")
+}
+
+// Expr is SQL written by the caller with the values of its ? placeholders.
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+func E(sql string, args ...interface{}) Expr {
+	return Expr{SQL: sql, Args: args}
+}
+
+// builder is a query under construction, its placeholders are numbered by build.
+// The first invalid part of the query is kept in err and returned by build.
+type builder struct {
+	sql  strings.Builder
+	args []interface{}
+	err  error
+}
+
+func (b *builder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *builder) write(s ...string) {
+	for _, s := range s {
+		b.sql.WriteString(s)
+	}
+}
+
+func (b *builder) expr(e Expr) {
+	b.sql.WriteString(e.SQL)
+	b.args = append(b.args, e.Args...)
+}
+
+func (b *builder) exprs(exprs []Expr, sep string) {
+	for i, e := range exprs {
+		if i != 0 {
+			b.sql.WriteString(sep)
+		}
+		b.expr(e)
+	}
+}
+
+// build numbers the ? placeholders that are outside of literals and comments.
+func (b *builder) build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	var (
+		query = b.sql.String()
+		sql   strings.Builder
+		last  int
+		n     int
+	)
+	for _, t := range lexer.Tokenize(query) {
+		if t.Kind == lexer.Punct && t.Text == "?" {
+			n++
+			sql.WriteString(query[last:t.Pos])
+			sql.WriteString("$" + strconv.Itoa(n))
+			last = t.Pos + 1
+		}
+	}
+	sql.WriteString(query[last:])
+	return sql.String(), b.args, nil
+}
+
+// interval renders a duration in the shorthand of Proton, in the largest unit that divides it.
+// The smallest unit is the millisecond: a duration that is not a positive number of milliseconds
+// is an error.
+func interval(d time.Duration) (string, error) {
+	if d <= 0 || d%time.Millisecond != 0 {
+		return "", fmt.Errorf("sqlb: invalid interval %s, it must be a positive number of milliseconds", d)
+	}
+	for _, unit := range []struct {
+		d    time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	} {
+		if d%unit.d == 0 {
+			return strconv.FormatInt(int64(d/unit.d), 10) + unit.name, nil
+		}
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms", nil
+}
+
+var settingNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Setting is an entry of a SETTINGS clause, its value is rendered as a literal: settings
+// cannot be bound as query parameters. A time.Duration is rejected, the settings do not
+// agree on a unit: pass the number of seconds or milliseconds the setting expects.
+type Setting struct {
+	Name  string
+	Value interface{}
+}
+
+func writeSettings(b *builder, settings []Setting) {
+	if len(settings) == 0 {
+		return
+	}
+	b.write(" SETTINGS ")
+	for i, s := range settings {
+		if i != 0 {
+			b.write(", ")
+		}
+		if !settingNameRe.MatchString(s.Name) {
+			b.fail(fmt.Errorf("sqlb: invalid setting name %q", s.Name))
+			return
+		}
+		value, err := settingValue(s.Value)
+		if err != nil {
+			b.fail(fmt.Errorf("sqlb: setting %s: %w", s.Name, err))
+			return
+		}
+		b.write(s.Name, " = ", value)
+	}
+}
+
+var errDurationSetting = errors.New("a time.Duration has no unit, pass the number of seconds or milliseconds the setting expects")
+
+func settingValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	case time.Duration:
+		return "", errDurationSetting
+	case time.Time:
+		return lexer.QuoteString(v.UTC().Format("2006-01-02 15:04:05.000")), nil
+	}
+	return lexer.QuoteString(fmt.Sprint(v)), nil
+}
@@ -0,0 +1,188 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+	"github.com/timeplus-io/proton-go-driver/v2/protontest"
+	"github.com/timeplus-io/proton-go-driver/v2/sqlb"
+)
+
+func TestIdent(t *testing.T) {
+	assert.Equal(t, "`events`", sqlb.Ident("events"))
+	assert.Equal(t, "`db`.`events`", sqlb.Ident("db.events"))
+	assert.Equal(t, "`a.b`", sqlb.QuoteIdent("a.b"))
+	assert.Equal(t, "`x\\` FROM t; --`", sqlb.Ident("x` FROM t; --"))
+	assert.Equal(t, "`a\\\\`", sqlb.Ident(`a\`))
+}
+
+func TestSelect(t *testing.T) {
+	query, args, err := sqlb.Select("device", "speed").
+		Expr("avg(temperature) AS avg").
+		From("readings").
+		Where("region = ?", "eu").
+		Where("speed > ? AND note != '?'", 10).
+		OrderByDesc("speed").
+		OrderBy("device").
+		Limit(5).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `device`, `speed`, avg(temperature) AS avg FROM `readings`"+
+		" WHERE (region = $1) AND (speed > $2 AND note != '?')"+
+		" ORDER BY `speed` DESC, `device` LIMIT 5", query)
+	assert.Equal(t, []interface{}{"eu", 10}, args)
+
+	query, args, err = sqlb.Select().FromTable("db.readings").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM table(`db`.`readings`)", query)
+	assert.Empty(t, args)
+}
+
+func TestSelectStreaming(t *testing.T) {
+	for expected, s := range map[string]*sqlb.SelectBuilder{
+		"SELECT `device`, count() FROM tumble(`readings`, 5s) WHERE kind = $1 GROUP BY `device`, `window_start` EMIT AFTER WATERMARK AND DELAY 2s": sqlb.Select("device").
+			Expr("count()").
+			FromWindow(sqlb.Tumble("readings", 5*time.Second)).
+			Where("kind = ?", "car").
+			GroupBy("device", "window_start").
+			Emit(sqlb.EmitAfterWatermarkAndDelay(2 * time.Second)),
+		"SELECT count() FROM hop(table(`readings`), `ts`, 1m, 1h) GROUP BY `window_start`": sqlb.Select().
+			Expr("count()").
+			FromWindow(sqlb.Hop("readings", time.Minute, time.Hour).TimeColumn("ts").Table()).
+			GroupBy("window_start"),
+		"SELECT max(speed) FROM session(`readings`, 1500ms) GROUP BY `window_start` HAVING max(speed) > $1 EMIT PERIODIC 1d": sqlb.Select().
+			Expr("max(speed)").
+			FromWindow(sqlb.Session("readings", 1500*time.Millisecond)).
+			GroupBy("window_start").
+			Having("max(speed) > ?", 100).
+			Emit(sqlb.EmitPeriodic(24 * time.Hour)),
+		"SELECT * FROM `readings` EMIT CHANGELOG SETTINGS seek_to = 'earliest', max_threads = 2": sqlb.Select().
+			From("readings").
+			Emit(sqlb.EmitChangelog()).
+			SeekTo("earliest").
+			Settings("max_threads", 2),
+		"SELECT `n` FROM (SELECT `n` FROM `numbers` WHERE n > $1) AS `sub` WHERE n < $2": sqlb.Select("n").
+			FromSelect(sqlb.Select("n").From("numbers").Where("n > ?", 1), "sub").
+			Where("n < ?", 10),
+	} {
+		query, _, err := s.Build()
+		require.NoError(t, err)
+		assert.Equal(t, expected, query)
+	}
+}
+
+func TestInvalidSettings(t *testing.T) {
+	for _, b := range []interface {
+		Build() (string, []interface{}, error)
+	}{
+		sqlb.Select().Settings("seek_to = 'latest'; DROP", 1),
+		sqlb.Select().From("numbers").Settings("max_execution_time", 5*time.Second),
+		sqlb.Select().FromSelect(sqlb.Select().Settings("max_execution_time", time.Second), "sub"),
+		sqlb.Insert("archive").Select(sqlb.Select().Settings("bad name", 1)),
+		sqlb.CreateStream("devices").Column("id", "string").Settings("ttl", time.Hour),
+	} {
+		query, args, err := b.Build()
+		assert.Error(t, err)
+		assert.Empty(t, query)
+		assert.Nil(t, args)
+	}
+	query, _, err := sqlb.Select().From("numbers").Settings("max_execution_time", 5).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `numbers` SETTINGS max_execution_time = 5", query)
+}
+
+func TestInvalidInterval(t *testing.T) {
+	for _, s := range []*sqlb.SelectBuilder{
+		sqlb.Select().FromWindow(sqlb.Tumble("readings", 0)),
+		sqlb.Select().FromWindow(sqlb.Hop("readings", time.Second, -time.Minute)),
+		sqlb.Select().FromWindow(sqlb.Session("readings", 1500*time.Microsecond)),
+		sqlb.Select().From("readings").Emit(sqlb.EmitPeriodic(time.Microsecond)),
+		sqlb.Select().From("readings").Emit(sqlb.EmitAfterWatermarkAndDelay(0)),
+	} {
+		query, args, err := s.Build()
+		assert.Error(t, err)
+		assert.Empty(t, query)
+		assert.Nil(t, args)
+	}
+	query, _, err := sqlb.Select().FromWindow(sqlb.Tumble("readings", time.Millisecond)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM tumble(`readings`, 1ms)", query)
+}
+
+func TestInsert(t *testing.T) {
+	query, args, err := sqlb.Insert("readings").Columns("device", "speed").Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `readings` (`device`, `speed`)", query)
+	assert.Empty(t, args)
+
+	query, args, err = sqlb.Insert("readings").Columns("device", "speed").
+		Values("a", 1).
+		Values("b", 2).
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `readings` (`device`, `speed`) VALUES ($1, $2), ($3, $4)", query)
+	assert.Equal(t, []interface{}{"a", 1, "b", 2}, args)
+
+	query, args, err = sqlb.Insert("archive").Select(sqlb.Select().FromTable("readings").Where("speed > ?", 5)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `archive` SELECT * FROM table(`readings`) WHERE speed > $1", query)
+	assert.Equal(t, []interface{}{5}, args)
+}
+
+func TestCreateStream(t *testing.T) {
+	query, args, err := sqlb.CreateStream("devices").
+		IfNotExists().
+		Column("id", "string").
+		ColumnDefault("updated", "datetime64(3)", "now64(3)").
+		PrimaryKey("id").
+		Comment("it's the devices").
+		Mode("versioned_kv").
+		Build()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE STREAM IF NOT EXISTS `devices` (`id` string, `updated` datetime64(3) DEFAULT now64(3))"+
+		" PRIMARY KEY `id` COMMENT 'it\\'s the devices' SETTINGS mode = 'versioned_kv'", query)
+	assert.Empty(t, args)
+}
+
+func TestQuery(t *testing.T) {
+	var received string
+	srv := protontest.NewServer(protontest.HandlerFunc(func(w *protontest.ResponseWriter, q *protontest.Query) error {
+		received = q.Body
+		var block proto.Block
+		block.AddColumn("n", "uint64")
+		return w.Data(&block)
+	}))
+	defer srv.Close()
+	conn, err := proton.Open(&proton.Options{Addr: []string{srv.Addr()}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	query, args, err := sqlb.Select("n").From("numbers").Where("s = ? AND n IN (?)", "it's", []int{1, 2}).Build()
+	require.NoError(t, err)
+	rows, err := conn.Query(context.Background(), query, args...)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	assert.Equal(t, "SELECT `n` FROM `numbers` WHERE s = 'it\\'s' AND n IN (1, 2)", received)
+}
@@ -0,0 +1,106 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+// flakyConn fails every write once broken is set, as if the network went down.
+type flakyConn struct {
+	net.Conn
+	broken *int32
+}
+
+func (c *flakyConn) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(c.broken) == 1 {
+		c.Conn.Close()
+		return 0, &net.OpError{Op: "write", Net: "tcp", Err: errors.New("connection reset by peer")}
+	}
+	return c.Conn.Write(p)
+}
+
+func TestBatchRetry(t *testing.T) {
+	var (
+		broken    int32
+		dialCount int32
+		ctx       = proton.Context(context.Background(), proton.WithBatchRetry(proton.RetryPolicy{
+			MaxRetries: 3,
+			Backoff:    10 * time.Millisecond,
+		}))
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+				var d net.Dialer
+				conn, err := d.DialContext(ctx, "tcp", addr)
+				if err != nil {
+					return nil, err
+				}
+				if atomic.AddInt32(&dialCount, 1) == 1 {
+					return &flakyConn{Conn: conn, broken: &broken}, nil
+				}
+				return conn, nil
+			},
+			MaxOpenConns: 1,
+		})
+	)
+	if assert.NoError(t, err) {
+		const ddl = `
+		CREATE STREAM test_batch_retry (
+			  Col1 uint64
+			, Col2 string
+		)
+		`
+		defer func() {
+			conn.Exec(context.Background(), "DROP STREAM test_batch_retry")
+		}()
+		if err := conn.Exec(ctx, ddl); !assert.NoError(t, err) {
+			return
+		}
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_batch_retry (* except _tp_time)")
+		if !assert.NoError(t, err) {
+			return
+		}
+		for i := 0; i < 1000; i++ {
+			if err := batch.Append(uint64(i), "value"); !assert.NoError(t, err) {
+				return
+			}
+		}
+		atomic.StoreInt32(&broken, 1)
+		if assert.NoError(t, batch.Send()) {
+			assert.Equal(t, int32(2), atomic.LoadInt32(&dialCount))
+			var count uint64
+			if err := conn.QueryRow(ctx, "SELECT count() FROM table(test_batch_retry)").Scan(&count); assert.NoError(t, err) {
+				assert.Equal(t, uint64(1000), count)
+			}
+		}
+	}
+}
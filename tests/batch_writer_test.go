@@ -0,0 +1,91 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestBatchWriter(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Compression: &proton.Compression{
+				Method: proton.CompressionLZ4,
+			},
+			MaxOpenConns: 4,
+		})
+	)
+	if assert.NoError(t, err) {
+		const ddl = `
+		CREATE STREAM test_batch_writer (
+			  Col1 uint64
+			, Col2 string
+		)
+		`
+		defer func() {
+			conn.Exec(ctx, "DROP STREAM test_batch_writer")
+		}()
+		if err := conn.Exec(ctx, ddl); !assert.NoError(t, err) {
+			return
+		}
+		writer := proton.NewBatchWriter(conn, "INSERT INTO test_batch_writer (Col1, Col2)", proton.BatchWriterOptions{
+			MaxRows:       1000,
+			FlushInterval: 100 * time.Millisecond,
+			OnError: func(err error, rows int) {
+				t.Errorf("flush of %d rows: %v", rows, err)
+			},
+		})
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 2500; i++ {
+					assert.NoError(t, writer.AppendStruct(&struct {
+						Col1 uint64
+						Col2 string
+					}{
+						Col1: uint64(g*2500 + i),
+						Col2: "value",
+					}))
+				}
+			}(g)
+		}
+		wg.Wait()
+		if assert.NoError(t, writer.Close()) {
+			var count, distinct uint64
+			if err := conn.QueryRow(ctx, "SELECT count(), uniq_exact(Col1) FROM table(test_batch_writer)").Scan(&count, &distinct); assert.NoError(t, err) {
+				assert.Equal(t, uint64(10000), count)
+				assert.Equal(t, uint64(10000), distinct)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestCancelQuery(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+		CancelGracePeriod: 500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	var (
+		queryID     = uuid.NewString()
+		ctx, cancel = context.WithTimeout(proton.Context(context.Background(), proton.WithQueryID(queryID)), 500*time.Millisecond)
+	)
+	defer cancel()
+	err = conn.Exec(ctx, "SELECT sleep(3) FROM numbers(10) SETTINGS max_block_size = 1")
+	assert.Error(t, err)
+	assert.True(t, proton.IsTimeout(err), err)
+	// the query is gone from the server, after the cancel or the KILL QUERY fallback
+	assert.Eventually(t, func() bool {
+		var running uint64
+		if err := conn.QueryRow(context.Background(), "SELECT count() FROM system.processes WHERE query_id = $1", queryID).Scan(&running); err != nil {
+			return false
+		}
+		return running == 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,91 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestCompressionZSTD(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Compression: &proton.Compression{
+				Method: proton.CompressionZSTD,
+			},
+			CompressionLevel: 3,
+			MaxOpenConns:     1,
+		})
+	)
+	if assert.NoError(t, err) {
+		const ddl = `
+		CREATE STREAM test_compression_zstd (
+			  Col1 uint64
+			, Col2 string
+		)
+		`
+		defer func() {
+			conn.Exec(ctx, "DROP STREAM test_compression_zstd")
+		}()
+		if err := conn.Exec(ctx, ddl); !assert.NoError(t, err) {
+			return
+		}
+		batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_compression_zstd (* except _tp_time)")
+		if !assert.NoError(t, err) {
+			return
+		}
+		for i := 0; i < 100_000; i++ {
+			if err := batch.Append(uint64(i), fmt.Sprintf("value_%d", i)); !assert.NoError(t, err) {
+				return
+			}
+		}
+		if !assert.NoError(t, batch.Send()) {
+			return
+		}
+		rows, err := conn.Query(ctx, "SELECT Col1, Col2 FROM table(test_compression_zstd) ORDER BY Col1")
+		if !assert.NoError(t, err) {
+			return
+		}
+		var i uint64
+		for rows.Next() {
+			var (
+				col1 uint64
+				col2 string
+			)
+			if assert.NoError(t, rows.Scan(&col1, &col2)) {
+				assert.Equal(t, i, col1)
+				assert.Equal(t, fmt.Sprintf("value_%d", i), col2)
+			}
+			i++
+		}
+		if assert.NoError(t, rows.Err()) {
+			assert.Equal(t, uint64(100_000), i)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestDescribeStream(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+	const ddl = `
+		CREATE STREAM test_describe (
+			id   uint64 COMMENT 'the id'
+		  , tags array(nullable(string)) CODEC(ZSTD(1))
+		) PRIMARY KEY id SETTINGS mode = 'versioned_kv'
+		`
+	defer func() {
+		conn.Exec(ctx, "DROP VIEW IF EXISTS test_describe_view")
+		conn.Exec(ctx, "DROP STREAM IF EXISTS test_describe")
+	}()
+	require.NoError(t, conn.Exec(ctx, ddl))
+	require.NoError(t, conn.Exec(ctx, "CREATE VIEW test_describe_view AS SELECT id FROM test_describe"))
+
+	description, err := conn.(proton.StreamDescriber).DescribeStream(ctx, "test_describe")
+	require.NoError(t, err)
+	assert.Equal(t, "default", description.Database)
+	assert.Equal(t, proton.StreamKindVersionedKV, description.Kind)
+	columns := make(map[string]proton.StreamColumn)
+	for _, c := range description.Columns {
+		columns[c.Name] = c
+	}
+	if assert.Contains(t, columns, "id") {
+		assert.Equal(t, "the id", columns["id"].Comment)
+		assert.NotNil(t, columns["id"].Column)
+	}
+	if assert.Contains(t, columns, "tags") {
+		assert.Contains(t, columns["tags"].Codec, "ZSTD")
+	}
+	assert.Contains(t, columns, "_tp_time")
+
+	streams, err := conn.(proton.StreamDescriber).ListStreams(ctx, "default")
+	require.NoError(t, err)
+	kinds := make(map[string]proton.StreamKind)
+	for _, stream := range streams {
+		kinds[stream.Name] = stream.Kind
+	}
+	assert.Equal(t, proton.StreamKindVersionedKV, kinds["test_describe"])
+	assert.Equal(t, proton.StreamKindView, kinds["test_describe_view"])
+
+	_, err = conn.(proton.StreamDescriber).DescribeStream(ctx, "stream_that_does_not_exist")
+	assert.True(t, proton.IsTableNotFound(err))
+}
@@ -0,0 +1,69 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/proto"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	err = conn.Exec(ctx, "SELECT * FROM stream_that_does_not_exist")
+	assert.True(t, proton.IsTableNotFound(err), err)
+	assert.False(t, proton.IsRetryable(err))
+	var exception *proton.Exception
+	if assert.True(t, errors.As(err, &exception)) {
+		assert.Equal(t, proto.CategoryTableNotFound, exception.Category())
+	}
+
+	err = conn.Exec(ctx, "SELEC 1")
+	assert.True(t, proton.IsSyntaxError(err), err)
+	assert.ErrorIs(t, err, proton.ErrSyntax)
+
+	wrong, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "wrong password",
+		},
+	})
+	require.NoError(t, err)
+	defer wrong.Close()
+	err = wrong.Ping(ctx)
+	assert.True(t, proton.IsAuthFailure(err), err)
+	assert.False(t, proton.IsRetryable(err))
+}
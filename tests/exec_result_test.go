@@ -0,0 +1,61 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestExecResult(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+	defer func() {
+		conn.Exec(ctx, "DROP STREAM IF EXISTS test_exec_result")
+	}()
+	require.NoError(t, conn.Exec(ctx, "CREATE STREAM test_exec_result (n uint64)"))
+
+	result, err := conn.(proton.ResultExecer).ExecWithResult(ctx, "INSERT INTO test_exec_result (n) SELECT number FROM numbers(10)")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), result.WrittenRows)
+	assert.NotZero(t, result.WrittenBytes)
+	assert.NotEmpty(t, result.QueryID)
+
+	batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_exec_result (n)")
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, batch.Append(uint64(i)))
+	}
+	result, err = batch.(proton.ResultSender).SendWithResult()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), result.WrittenRows)
+	assert.Equal(t, batch.(proton.QueryIdentifier).QueryID(), result.QueryID)
+}
@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestHostHealthCheck(t *testing.T) {
+	const dead = "127.0.0.1:8464"
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{dead, "127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+		ConnOpenStrategy:    proton.ConnOpenLeastConnections,
+		HealthCheckInterval: 100 * time.Millisecond,
+		HostEjectTime:       time.Minute,
+	})
+	if assert.NoError(t, err) {
+		defer conn.Close()
+		if assert.NoError(t, conn.Ping(context.Background())) {
+			stats := conn.Stats()
+			if assert.Len(t, stats.Hosts, 2) {
+				assert.Equal(t, dead, stats.Hosts[0].Addr)
+				assert.False(t, stats.Hosts[0].Healthy)
+				assert.Error(t, stats.Hosts[0].LastError)
+				assert.True(t, stats.Hosts[1].Healthy)
+			}
+		}
+		assert.Eventually(t, func() bool {
+			stats := conn.Stats()
+			return !stats.Hosts[0].LastCheck.IsZero() && !stats.Hosts[1].LastCheck.IsZero() && stats.Hosts[1].Healthy
+		}, 5*time.Second, 50*time.Millisecond)
+	}
+}
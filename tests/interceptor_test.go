@@ -0,0 +1,77 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestInterceptors(t *testing.T) {
+	var (
+		methods  []string
+		queryIDs []string
+		tag      = func(ctx context.Context, inv *proton.Invocation, next proton.Invoker) (interface{}, error) {
+			methods = append(methods, inv.Method)
+			inv.Options.Settings()["max_threads"] = 1
+			if err := inv.Options.Apply(proton.WithQueryID("tagged-" + inv.Method)); err != nil {
+				return nil, err
+			}
+			result, err := next(ctx, inv)
+			queryIDs = append(queryIDs, inv.Options.QueryID())
+			return result, err
+		}
+		opt = &proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Interceptors: []proton.Interceptor{tag},
+		}
+	)
+	conn, err := proton.Open(opt)
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+	var threads string
+	require.NoError(t, conn.QueryRow(ctx, "SELECT value FROM system.settings WHERE name = 'max_threads'").Scan(&threads))
+	assert.Equal(t, "1", threads)
+	require.NoError(t, conn.Ping(ctx))
+	assert.Equal(t, []string{proton.MethodQueryRow, proton.MethodPing}, methods)
+	assert.Equal(t, []string{"tagged-QueryRow", "tagged-Ping"}, queryIDs)
+
+	methods = nil
+	db := proton.OpenDB(&proton.Options{
+		Addr:         opt.Addr,
+		Auth:         opt.Auth,
+		Interceptors: []proton.Interceptor{tag},
+	})
+	defer db.Close()
+	require.NoError(t, db.QueryRow("SELECT value FROM system.settings WHERE name = 'max_threads'").Scan(&threads))
+	assert.Equal(t, "1", threads)
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+	assert.Contains(t, methods, proton.MethodQuery)
+	assert.Contains(t, methods, proton.MethodExec)
+}
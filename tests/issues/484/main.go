@@ -37,7 +37,7 @@ func main() {
 		},
 		DialTimeout: 5 * time.Second,
 		Compression: &proton.Compression{
-			proton.CompressionLZ4,
+			Method: proton.CompressionLZ4,
 		},
 		//Debug: true,
 	})
@@ -0,0 +1,98 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+	"github.com/timeplus-io/proton-go-driver/v2/lib/column"
+)
+
+func TestNextBlock(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+		Compression: &proton.Compression{
+			Method: proton.CompressionLZ4,
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx := proton.Context(context.Background(), proton.WithSettings(proton.Settings{
+		"max_block_size": 100,
+	}))
+	rows, err := conn.Query(ctx, `
+		SELECT
+			  to_int64(number)
+			, to_string(number)
+			, to_datetime(number, 'UTC')
+			, if(number % 2 = 0, NULL, to_nullable(number))
+		FROM system.numbers LIMIT 1000
+	`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var total int
+	for {
+		block, err := rows.(proton.BlockReader).NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		ints, err := column.Int64s(block.Columns[0])
+		if !assert.NoError(t, err) {
+			return
+		}
+		strs, err := column.Strings(block.Columns[1])
+		if !assert.NoError(t, err) {
+			return
+		}
+		times, err := column.Times(block.Columns[2])
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = column.Strings(block.Columns[0])
+		assert.Error(t, err)
+		nulls := column.Nulls(block.Columns[3])
+		if assert.Len(t, nulls, block.Rows()) {
+			for i := range ints {
+				assert.Equal(t, ints[i]%2 == 0, nulls[i])
+			}
+		}
+		for i, v := range ints {
+			assert.Equal(t, int64(total+i), v)
+			assert.Equal(t, time.Unix(v, 0).UTC(), times[i].UTC())
+		}
+		assert.Len(t, strs, len(ints))
+		total += block.Rows()
+	}
+	assert.Equal(t, 1000, total)
+	assert.NoError(t, rows.Err())
+}
@@ -0,0 +1,58 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestPoolMinIdleConns(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+		MaxIdleConns:    3,
+		MinIdleConns:    2,
+		ConnMaxIdleTime: 200 * time.Millisecond,
+	})
+	if assert.NoError(t, err) {
+		defer conn.Close()
+		assert.Eventually(t, func() bool {
+			return conn.Stats().Idle == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, conn.Ping(context.Background()))
+		}
+		// idle connections are replaced once they reach ConnMaxIdleTime
+		assert.Eventually(t, func() bool {
+			stats := conn.Stats()
+			return stats.MaxIdleTimeClosed > 0 && stats.Idle == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		stats := conn.Stats()
+		assert.Equal(t, int64(0), stats.DialErrors)
+		assert.GreaterOrEqual(t, stats.Dials, int64(3))
+	}
+}
@@ -0,0 +1,66 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestQueryID(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	rows, err := conn.Query(ctx, "SELECT query_id()")
+	require.NoError(t, err)
+	_, err = uuid.Parse(rows.(proton.QueryIdentifier).QueryID())
+	assert.NoError(t, err)
+	if assert.True(t, rows.Next()) {
+		var queryID string
+		require.NoError(t, rows.Scan(&queryID))
+		assert.Equal(t, rows.(proton.QueryIdentifier).QueryID(), queryID)
+	}
+	require.NoError(t, rows.Close())
+
+	rows, err = conn.Query(proton.Context(ctx, proton.WithQueryID("my-query-id")), "SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, "my-query-id", rows.(proton.QueryIdentifier).QueryID())
+	require.NoError(t, rows.Close())
+
+	err = conn.Exec(ctx, "SELECT * FROM stream_that_does_not_exist")
+	var exception *proton.Exception
+	if assert.True(t, errors.As(err, &exception)) {
+		_, err := uuid.Parse(exception.QueryID)
+		assert.NoError(t, err)
+	}
+}
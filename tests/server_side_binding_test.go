@@ -0,0 +1,63 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestServerSideBinding(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			ServerSideBinding: true,
+			MaxOpenConns:      1,
+		})
+	)
+	if assert.NoError(t, err) {
+		var (
+			col1 int64
+			col2 string
+			col3 []string
+			col4 time.Time
+			now  = time.Now().Truncate(time.Second).UTC()
+		)
+		err := conn.QueryRow(ctx, "SELECT $1, $2, $3, $4", int64(42), "it's 'quoted'\t", []string{"a", "b'"}, now).Scan(&col1, &col2, &col3, &col4)
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(42), col1)
+			assert.Equal(t, "it's 'quoted'\t", col2)
+			assert.Equal(t, []string{"a", "b'"}, col3)
+			assert.Equal(t, now, col4.UTC())
+		}
+		var named string
+		if err := conn.QueryRow(ctx, "SELECT @value", proton.Named("value", "named")).Scan(&named); assert.NoError(t, err) {
+			assert.Equal(t, "named", named)
+		}
+	}
+}
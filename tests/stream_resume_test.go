@@ -0,0 +1,122 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestStreamResume(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		dialed    []net.Conn
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			DialContext: func(ctx context.Context, addr string) (net.Conn, error) {
+				var d net.Dialer
+				conn, err := d.DialContext(ctx, "tcp", addr)
+				if err == nil {
+					mutex.Lock()
+					dialed = append(dialed, conn)
+					mutex.Unlock()
+				}
+				return conn, err
+			},
+		})
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	ctx := context.Background()
+	const ddl = `
+		CREATE STREAM test_stream_resume (
+			Col1 uint32
+		)
+		`
+	defer func() {
+		conn.Exec(ctx, "DROP STREAM test_stream_resume")
+	}()
+	if !assert.NoError(t, conn.Exec(ctx, ddl)) {
+		return
+	}
+	insert := func(from, to int) {
+		if batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_stream_resume (Col1)"); assert.NoError(t, err) {
+			for i := from; i < to; i++ {
+				assert.NoError(t, batch.Append(uint32(i)))
+			}
+			assert.NoError(t, batch.Send())
+		}
+	}
+	stream, err := conn.(proton.Streamer).Stream(proton.Context(ctx, proton.WithStreamResume(proton.ResumePolicy{
+		Cursor:     "_tp_time",
+		MaxRetries: 5,
+	})), "SELECT Col1, _tp_time FROM test_stream_resume")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stream.Close()
+	time.Sleep(2 * time.Second)
+	insert(0, 5)
+	var received []uint32
+	rows := stream.Rows()
+	for row := range rows {
+		var (
+			col1   uint32
+			tpTime time.Time
+		)
+		if assert.NoError(t, row.Scan(&col1, &tpTime)) {
+			received = append(received, col1)
+		}
+		if len(received) == 5 {
+			break
+		}
+	}
+	mutex.Lock()
+	for _, c := range dialed {
+		c.Close()
+	}
+	mutex.Unlock()
+	time.Sleep(2 * time.Second)
+	insert(5, 10)
+	for row := range rows {
+		var (
+			col1   uint32
+			tpTime time.Time
+		)
+		if assert.NoError(t, row.Scan(&col1, &tpTime)) {
+			received = append(received, col1)
+		}
+		if len(received) == 10 {
+			break
+		}
+	}
+	assert.Equal(t, []uint32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, received)
+	assert.NoError(t, stream.Close())
+}
@@ -0,0 +1,125 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestStream(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		conn, err = proton.Open(&proton.Options{
+			Addr: []string{"127.0.0.1:8463"},
+			Auth: proton.Auth{
+				Database: "default",
+				Username: "default",
+				Password: "",
+			},
+			Compression: &proton.Compression{
+				Method: proton.CompressionLZ4,
+			},
+		})
+	)
+	if assert.NoError(t, err) {
+		const ddl = `
+		CREATE STREAM test_stream (
+			  Col1 uint8
+			, Col2 string
+		)
+		`
+		defer func() {
+			conn.Exec(ctx, "DROP STREAM test_stream")
+		}()
+		if err := conn.Exec(ctx, ddl); assert.NoError(t, err) {
+			stream, err := conn.(proton.Streamer).Stream(ctx, "SELECT Col1, Col2 FROM test_stream")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, []string{"Col1", "Col2"}, stream.Columns())
+			// give the streaming query time to subscribe before inserting
+			time.Sleep(2 * time.Second)
+			if batch, err := conn.PrepareBatch(ctx, "INSERT INTO test_stream (Col1, Col2)"); assert.NoError(t, err) {
+				for i := 0; i < 10; i++ {
+					if !assert.NoError(t, batch.Append(uint8(i), "value")) {
+						return
+					}
+				}
+				if !assert.NoError(t, batch.Send()) {
+					return
+				}
+			}
+			var received int
+			for row := range stream.Rows() {
+				var result struct {
+					Col1 uint8
+					Col2 string
+				}
+				if assert.NoError(t, row.ScanStruct(&result)) {
+					assert.Equal(t, uint8(received), result.Col1)
+					assert.Equal(t, "value", result.Col2)
+				}
+				if received++; received == 10 {
+					break
+				}
+			}
+			assert.Equal(t, 10, received)
+			closed := make(chan error)
+			go func() {
+				closed <- stream.Close()
+			}()
+			select {
+			case err := <-closed:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream.Close() hangs on an unbounded query")
+			}
+		}
+	}
+}
+
+func TestStreamContextCancel(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	if assert.NoError(t, err) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream, err := conn.(proton.Streamer).Stream(ctx, "SELECT number FROM numbers(1000000000)")
+		if !assert.NoError(t, err) {
+			return
+		}
+		<-stream.Blocks()
+		cancel()
+		for range stream.Blocks() {
+		}
+		assert.Equal(t, context.Canceled, stream.Close())
+		assert.Greater(t, stream.Progress().Rows, uint64(0))
+		assert.NoError(t, conn.Ping(context.Background()))
+	}
+}
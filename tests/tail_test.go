@@ -0,0 +1,63 @@
+// Licensed to ClickHouse, Inc. under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. ClickHouse, Inc. licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/timeplus-io/proton-go-driver/v2"
+)
+
+func TestTail(t *testing.T) {
+	conn, err := proton.Open(&proton.Options{
+		Addr: []string{"127.0.0.1:8463"},
+		Auth: proton.Auth{
+			Database: "default",
+			Username: "default",
+			Password: "",
+		},
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	defer conn.Exec(context.Background(), "DROP STREAM IF EXISTS test_tail")
+	require.NoError(t, conn.Exec(ctx, "CREATE STREAM test_tail (id uint64)"))
+	require.NoError(t, conn.Exec(ctx, "INSERT INTO test_tail (id) VALUES (1), (2), (3)"))
+	time.Sleep(2 * time.Second) // the history is readable once the rows are committed
+
+	rows, err := conn.(proton.Tailer).Tail(ctx, "test_tail", time.Now().Add(-time.Hour), proton.TailOptions{
+		Columns: []string{"id"},
+	})
+	require.NoError(t, err)
+	defer rows.Close()
+	var ids []uint64
+	for len(ids) < 5 && rows.Next() {
+		var id uint64
+		require.NoError(t, rows.Scan(&id))
+		if ids = append(ids, id); len(ids) == 3 {
+			require.NoError(t, conn.Exec(ctx, "INSERT INTO test_tail (id) VALUES (4), (5)"))
+		}
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, ids)
+}